@@ -14,6 +14,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"time"
 
 	"github.com/google/gnxi/utils/credentials"
@@ -34,14 +35,47 @@ var (
 	bindAddr             = flag.String("bind_address", ":10161", "Bind to address:port or just :port")
 	metricAddr           = flag.String("metric_address", ":9851", "Prometheus metric endpoint bind to address:port or just :port")
 	configFile           = flag.String("config", "", "IETF JSON file for target startup config")
-	outputFileName       = flag.String("output", "", "JSON file to save output to")
+	outputFileName       = flag.String("output", "", "File (*.json, *.jsonl) or directory in which to record the JSON pushed to the core and UPF")
 	partialUpdateDisable = flag.Bool("partial_update_disable", false, "Disable partial update; send full updates to core on every change")
-	postDisable          = flag.Bool("post_disable", false, "Disable posting to connectivity service endpoints")
+	postDisable          = flag.Bool("post_disable", false, "Disable posting to connectivity service endpoints; use with --output to render only")
+	dryRun               = flag.Bool("dry_run", false, "Compute the pushes that would be made, and report them as a plan via the diagnostic API, without pushing")
 	postTimeout          = flag.Duration("post_timeout", time.Second*10, "Timeout duration when making post requests")
+	retryInterval        = flag.Duration("retry_interval", synchronizer.DefaultRetryInterval, "Delay before the first retry of a failed push; doubles on each subsequent retry")
+	retryMaxInterval     = flag.Duration("retry_max_interval", synchronizer.DefaultRetryMaxInterval, "Maximum delay between retries of a failed push")
+	syncWorkers          = flag.Int("sync_workers", synchronizer.DefaultWorkers, "Number of sites to synchronize in parallel")
+	reconcileInterval    = flag.Duration("reconcile_interval", 0, "Interval between checks of the core for device-groups and slices that are not in the model; 0 to disable")
+	strictMode           = flag.Bool("strict_mode", false, "Do not acknowledge a Set until it has been pushed; reject it, and roll it back, if the core or UPF rejects it")
+	strictTimeout        = flag.Duration("strict_timeout", synchronizer.DefaultStrictTimeout, "In --strict_mode, how long a Set waits for its pushes before it is rejected; no other Set is served while it waits")
+	validateReferences   = flag.Bool("validate_references", false, "Reject a Set with dangling references, duplicate IMSIs, overlapping UE subnets, or disabled ip-domains that device-groups still use")
+	targetedSync         = flag.Bool("targeted_sync", synchronizer.DefaultTargetedSyncEnable, "Render and push only the device-groups and slices affected by a Set")
+	driftCheckInterval   = flag.Duration("drift_check_interval", 0, "Interval between comparisons of the device-groups and slices on the core against the model; 0 to disable")
+	driftRepush          = flag.Bool("drift_repush", false, "Push device-groups and slices that have drifted on the core, rather than waiting for the next synchronization")
+	upfFailoverWindow    = flag.Duration("upf_failover_window", 0, "How long a slice's UPF config endpoint must be unreachable before the slice fails over to a standby of its UPF; 0 to disable")
+	upfStandbys          = flag.String("upf_standbys", "", "Standby UPFs of each UPF for --upf_failover_window, as upf=standby[+standby...][,upf=...]; a standby must be in the same site as its UPF")
+	upfProbeInterval     = flag.Duration("upf_probe_interval", synchronizer.DefaultUpfProbeInterval, "Interval between probes of the UPF config endpoints, with --upf_failover_window")
+	fqdnRefreshInterval  = flag.Duration("fqdn_refresh_interval", synchronizer.DefaultFQDNRefreshInterval, "Interval between resolving the host names in application addresses again; 0 to disable")
+	rulePriorityMode     = flag.String("rule_priority_mode", synchronizer.DefaultRulePriorityMode, "How application filtering rule priorities are assigned: passthrough renders them as given, reassign numbers them so they cannot conflict, strict fails slices whose rules conflict")
+	sliceTemplates       = flag.String("slice_templates", "", "Template that each slice inherits its unset fields from, as slice=template[,slice=...]")
+	defaultSliceTemplate = flag.String("default_slice_template", "", "Template that slices not in --slice_templates inherit their unset fields from; if empty, they have no template")
+	filterPolicyFile     = flag.String("filter_policy_file", "", "YAML file of filter policies for slice default-behaviors, in addition to ALLOW-ALL, DENY-ALL and ALLOW-PUBLIC")
+	reconcileSafeMode    = flag.Bool("reconcile_safe_mode", synchronizer.DefaultReconcileSafeMode, "Report objects found by reconcile, but do not delete them")
+	pushCACert           = flag.String("push_ca_cert", "", "CA certificate used to verify the core and UPF endpoints")
+	pushClientCert       = flag.String("push_client_cert", "", "Client certificate presented to the core and UPF endpoints")
+	pushClientKey        = flag.String("push_client_key", "", "Key for --push_client_cert")
+	pushBearerTokenFile  = flag.String("push_bearer_token_file", "", "File holding a bearer token to send to the core and UPF endpoints")
+	pushBasicAuthUser    = flag.String("push_basic_auth_user", "", "Username for basic auth to the core and UPF endpoints")
+	pushBasicAuthPwFile  = flag.String("push_basic_auth_password_file", "", "File holding the password for --push_basic_auth_user")
+	pushMaxIdleConns     = flag.Int("push_max_idle_conns", synchronizer.DefaultMaxIdleConnsPerHost, "Number of idle connections to keep open to each core and UPF endpoint")
+	pushMethodSlice      = flag.String("push_method_network_slice", synchronizer.DefaultUpdateMethods[synchronizer.EndpointTypeNetworkSlice], "HTTP method (PUT or POST) for updates to core network-slice endpoints; PUT falls back to POST if unsupported")
+	pushMethodDG         = flag.String("push_method_device_group", synchronizer.DefaultUpdateMethods[synchronizer.EndpointTypeDeviceGroup], "HTTP method (PUT or POST) for updates to core device-group endpoints; PUT falls back to POST if unsupported")
+	pushMethodUpf        = flag.String("push_method_upf", synchronizer.DefaultUpdateMethods[synchronizer.EndpointTypeUpfNetworkSlices], "HTTP method (PUT or POST) for updates to UPF network-slices endpoints; PUT falls back to POST if unsupported")
 	aetherConfigAddr     = flag.String("aether_config_addr", "", "If specified, pull initial state from aether-config at this address")
 	aetherConfigTarget   = flag.String("aether_config_target", "connectivity-service-v4", "Target to use when pulling from aether-config")
 	showModelList        = flag.Bool("show_models", false, "Show list of available modes")
 	diagsPort            = flag.Uint("diags_port", 8080, "Port to use for Diagnostics API")
+	configStoreDir       = flag.String("config_store_dir", "", "If specified, persist configuration to this directory and restore it on startup")
+	snapshotInterval     = flag.Duration("config_snapshot_interval", time.Minute*5, "Interval between snapshots of the persisted configuration")
+	cacheDir             = flag.String("cache_dir", synchronizer.DefaultCacheDir, "Directory in which to persist the push cache, so that a restart does not push unchanged objects again; if empty, the cache is kept in memory only")
 )
 
 var log = logging.GetLogger("sdcore-adapter")
@@ -55,11 +89,16 @@ func serveMetrics() {
 
 // Synchronize and eat the error. This lets aether-config know we applied the
 // configuration, but leaves us to retry applying it to the southbound device
-// ourselves.
-func synchronizerWrapper(s synchronizer.SynchronizerInterface) gnmi.ConfigCallback {
+// ourselves. In strict mode, errors applying a Set are returned, so that
+// aether-config sees the Set fail and the gNMI server rolls it back.
+func synchronizerWrapper(s synchronizer.SynchronizerInterface, strict bool) gnmi.ConfigCallback {
 	return func(config ygot.ValidatedGoStruct, callbackType gnmi.ConfigCallbackType, path *pb.Path) error {
 		err := s.Synchronize(config, callbackType, path)
 		if err != nil {
+			if strict && (callbackType == gnmi.Apply) {
+				log.Warnf("Rejecting update: %v", err)
+				return err
+			}
 			// Report the error, but do not send the error upstream.
 			log.Warnf("Error during synchronize: %v", err)
 		}
@@ -67,8 +106,20 @@ func synchronizerWrapper(s synchronizer.SynchronizerInterface) gnmi.ConfigCallba
 	}
 }
 
+// readSecretFile returns the trimmed contents of a file holding a token or password
+func readSecretFile(fileName string) string {
+	if fileName == "" {
+		return ""
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", fileName, err)
+	}
+	return strings.TrimSpace(string(data))
+}
+
 func main() {
-	var sync synchronizer.SynchronizerInterface
+	var sync *synchronizer.Synchronizer
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
@@ -81,11 +132,65 @@ func main() {
 
 	// Initialize the synchronizer's service-specific code.
 	log.Infof("Initializing synchronizer")
-	sync = synchronizer.NewSynchronizer(
+	pusher, err := synchronizer.NewRESTPusher(
+		synchronizer.WithPushTimeout(*postTimeout),
+		synchronizer.WithMaxIdleConnsPerHost(*pushMaxIdleConns),
+		synchronizer.WithTLS(*pushCACert, *pushClientCert, *pushClientKey),
+		synchronizer.WithBearerToken(readSecretFile(*pushBearerTokenFile)),
+		synchronizer.WithBasicAuth(*pushBasicAuthUser, readSecretFile(*pushBasicAuthPwFile)),
+		synchronizer.WithUpdateMethod(synchronizer.EndpointTypeNetworkSlice, *pushMethodSlice),
+		synchronizer.WithUpdateMethod(synchronizer.EndpointTypeDeviceGroup, *pushMethodDG),
+		synchronizer.WithUpdateMethod(synchronizer.EndpointTypeUpfNetworkSlices, *pushMethodUpf),
+	)
+	if err != nil {
+		log.Fatalf("Failed to create REST pusher: %v", err)
+	}
+	standbys, err := synchronizer.ParseUpfStandbys(*upfStandbys)
+	if err != nil {
+		log.Fatalf("Invalid --upf_standbys: %v", err)
+	}
+	templates, err := synchronizer.ParseSliceTemplates(*sliceTemplates)
+	if err != nil {
+		log.Fatalf("Invalid --slice_templates: %v", err)
+	}
+	syncOpts := []synchronizer.SynchronizerOption{
+		synchronizer.WithPusher(pusher),
 		synchronizer.WithOutputFileName(*outputFileName),
 		synchronizer.WithPostEnable(!*postDisable),
 		synchronizer.WithPartialUpdateEnable(!*partialUpdateDisable),
-		synchronizer.WithPostTimeout(*postTimeout))
+		synchronizer.WithPostTimeout(*postTimeout),
+		synchronizer.WithDryRun(*dryRun),
+		synchronizer.WithRetryInterval(*retryInterval),
+		synchronizer.WithRetryMaxInterval(*retryMaxInterval),
+		synchronizer.WithWorkers(*syncWorkers),
+		synchronizer.WithReconcileInterval(*reconcileInterval),
+		synchronizer.WithReconcileSafeMode(*reconcileSafeMode),
+		synchronizer.WithDriftInterval(*driftCheckInterval),
+		synchronizer.WithDriftRepush(*driftRepush),
+		synchronizer.WithStrictMode(*strictMode),
+		synchronizer.WithStrictTimeout(*strictTimeout),
+		synchronizer.WithTargetedSyncEnable(*targetedSync),
+		synchronizer.WithUpfFailoverWindow(*upfFailoverWindow),
+		synchronizer.WithUpfStandbys(standbys),
+		synchronizer.WithUpfProbeInterval(*upfProbeInterval),
+		synchronizer.WithFQDNRefreshInterval(*fqdnRefreshInterval),
+		synchronizer.WithRulePriorityMode(*rulePriorityMode),
+		synchronizer.WithSliceTemplates(templates, *defaultSliceTemplate),
+	}
+	if !synchronizer.ValidRulePriorityMode(*rulePriorityMode) {
+		log.Fatalf("Invalid --rule_priority_mode %s; must be passthrough, reassign or strict", *rulePriorityMode)
+	}
+	if *filterPolicyFile != "" {
+		policies, err := synchronizer.LoadFilterPolicies(*filterPolicyFile)
+		if err != nil {
+			log.Fatalf("Failed to load filter policies: %v", err)
+		}
+		syncOpts = append(syncOpts, synchronizer.WithFilterPolicies(policies))
+	}
+	if *cacheDir != "" {
+		syncOpts = append(syncOpts, synchronizer.WithCacheStore(synchronizer.NewFileCacheStore(*cacheDir)))
+	}
+	sync = synchronizer.NewSynchronizer(syncOpts...)
 
 	// The synchronizer will convey its list of models.
 	model := sync.GetModels()
@@ -123,20 +228,35 @@ func main() {
 		log.Infof("Fetching initial state from %s, target %s", *aetherConfigAddr, *aetherConfigTarget)
 		// The migration library has the functions for fetching from onos-config
 		srcVal, err := gnmiclient.GetPath(context.Background(), "", *aetherConfigTarget, *aetherConfigAddr)
-		if err != nil {
+		if err != nil && *configStoreDir != "" {
+			// We can still come up with the last config we persisted
+			log.Warnf("Error fetching initial data from onos-config, using persisted config: %s", err.Error())
+		} else if err != nil {
 			log.Fatalf("Error fetching initial data from onos-config: %s", err.Error())
 			return
-		}
+		} else {
+			configData = srcVal.GetJsonVal()
 
-		configData = srcVal.GetJsonVal()
+			log.Infof("Fetched config: %s", string(configData))
+		}
+	}
 
-		log.Infof("Fetched config: %s", string(configData))
+	var serverOpts []gnmi.ServerOption
+	if *configStoreDir != "" {
+		store, err := gnmi.NewConfigStore(*configStoreDir)
+		if err != nil {
+			log.Fatalf("error in opening config store: %v", err)
+		}
+		serverOpts = append(serverOpts, gnmi.WithConfigStore(store, *snapshotInterval))
+	}
+	if *validateReferences {
+		serverOpts = append(serverOpts, gnmi.WithValidator(synchronizer.ValidateConfig))
 	}
 
 	c := make(chan os.Signal, 1)
 	signal.Notify(c)
 
-	s, err := target.NewTarget(model, configData, synchronizerWrapper(sync))
+	s, err := target.NewTarget(model, configData, synchronizerWrapper(sync, *strictMode), serverOpts...)
 	if err != nil {
 		log.Fatalf("error in creating gnmi target: %v", err)
 	}
@@ -158,7 +278,7 @@ func main() {
 	go serveMetrics()
 
 	log.Infof("starting out-of-band API on %d", *diagsPort)
-	diagapi.StartDiagnosticAPI(s, *aetherConfigAddr, *aetherConfigTarget, *diagsPort)
+	diagapi.StartDiagnosticAPI(s, sync, *aetherConfigAddr, *aetherConfigTarget, *diagsPort)
 
 	log.Infof("starting to listen on %s", *bindAddr)
 	listen, err := net.Listen("tcp", *bindAddr)
@@ -26,19 +26,60 @@ package diagapi
  *
  *   # change the synchronizer log level
  *   curl -v -X POST http://localhost:8080/loglevel/root --data "DEBUG"
+ *
+ *   # show the pushes that would be made, when running with --dry_run
+ *   curl http://localhost:8080/plan
+ *
+ *   # show the synchronization status of all objects, all slices, or one slice
+ *   curl http://localhost:8080/status
+ *   curl http://localhost:8080/status/slice
+ *   curl http://localhost:8080/status/slice/my-slice
+ *
+ *   # find device-groups and slices on the core that are not in the model, and delete them
+ *   curl -X POST "http://localhost:8080/reconcile?safe=false"
+ *
+ *   # show the result of the most recent reconcile
+ *   curl http://localhost:8080/reconcile
+ *
+ *   # compare the device-groups and slices on the core against the model, pushing any that differ
+ *   curl -X POST "http://localhost:8080/drift?repush=true"
+ *
+ *   # show the result of the most recent drift check
+ *   curl http://localhost:8080/drift
+ *
+ *   # show what depends on a traffic-class, and the device-groups and slices a change to it would push
+ *   curl "http://localhost:8080/impact/traffic-class/my-tc?enterprise=my-ent"
+ *
+ *   # the same, for an object in a site
+ *   curl "http://localhost:8080/impact/ip-domain/my-ipd?enterprise=my-ent&site=my-site"
+ *
+ *   # show the values each slice is rendered with, including those inherited from its template
+ *   curl http://localhost:8080/effective-slices
+ *   curl http://localhost:8080/effective-slices/my-slice
+ *
+ *   # check the current config against the semantic validation rules
+ *   curl http://localhost:8080/validate
+ *
+ *   # check a config from a file, without loading it
+ *   curl --header "Content-Type: application/json" -X POST --data @state.json http://localhost:8080/validate
  */
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/onosproject/sdcore-adapter/pkg/gnmiclient"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/onosproject/onos-lib-go/pkg/logging"
 	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	"github.com/onosproject/sdcore-adapter/pkg/refgraph"
+	"github.com/onosproject/sdcore-adapter/pkg/synchronizer"
+	"github.com/onosproject/sdcore-adapter/pkg/validation"
 	pb "github.com/openconfig/gnmi/proto/gnmi"
 )
 
@@ -51,9 +92,24 @@ type TargetInterface interface {
 	PutJSON([]byte) error
 }
 
+// SynchronizerInterface is an interface to the Synchronizer
+type SynchronizerInterface interface {
+	GetPlan() *synchronizer.Plan
+	GetStatus(kind string, id string) []synchronizer.ObjectStatus
+	Reconcile(safeMode bool) (*synchronizer.ReconcileReport, error)
+	ReconcileSafeMode() bool
+	GetReconcileReport() *synchronizer.ReconcileReport
+	DriftCheck(repush bool) (*synchronizer.DriftReport, error)
+	DriftRepush() bool
+	GetDriftReport() *synchronizer.DriftReport
+	Impact(node refgraph.NodeID) (*refgraph.Impact, error)
+	GetEffectiveSlices(sliceID string) ([]synchronizer.EffectiveSlice, error)
+}
+
 // DiagnosticAPI is an api for performing diagnostic operations on the synchronizer
 type DiagnosticAPI struct {
 	targetServer            TargetInterface
+	synchronizer            SynchronizerInterface
 	defaultTarget           string
 	defaultAetherConfigAddr string
 }
@@ -145,6 +201,190 @@ func (m *DiagnosticAPI) pullFromOnosConfig(w http.ResponseWriter, r *http.Reques
 	fmt.Fprintf(w, "SUCCESS")
 }
 
+func (m *DiagnosticAPI) getPlan(w http.ResponseWriter, r *http.Request) {
+	_ = r
+	plan := m.synchronizer.GetPlan()
+	if plan == nil {
+		http.Error(w, "no plan has been computed; is the synchronizer in dry-run mode?", http.StatusNotFound)
+		return
+	}
+
+	jsonDump, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(jsonDump)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (m *DiagnosticAPI) getStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	kind := vars["kind"]
+	id := vars["id"]
+
+	status := m.synchronizer.GetStatus(kind, id)
+	if (id != "") && (len(status) == 0) {
+		http.Error(w, fmt.Sprintf("no status for %s %s", kind, id), http.StatusNotFound)
+		return
+	}
+
+	jsonDump, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(jsonDump)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (m *DiagnosticAPI) writeReport(w http.ResponseWriter, report interface{}) {
+	jsonDump, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(jsonDump)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func (m *DiagnosticAPI) getReconcile(w http.ResponseWriter, r *http.Request) {
+	_ = r
+	report := m.synchronizer.GetReconcileReport()
+	if report == nil {
+		http.Error(w, "no reconcile has been run", http.StatusNotFound)
+		return
+	}
+	m.writeReport(w, report)
+}
+
+func (m *DiagnosticAPI) postReconcile(w http.ResponseWriter, r *http.Request) {
+	safeMode := m.synchronizer.ReconcileSafeMode()
+	safeParam := r.URL.Query().Get("safe")
+	if safeParam != "" {
+		var err error
+		safeMode, err = strconv.ParseBool(safeParam)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid safe parameter %s", safeParam), http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := m.synchronizer.Reconcile(safeMode)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	m.writeReport(w, report)
+}
+
+func (m *DiagnosticAPI) getDrift(w http.ResponseWriter, r *http.Request) {
+	_ = r
+	report := m.synchronizer.GetDriftReport()
+	if report == nil {
+		http.Error(w, "no drift check has been run", http.StatusNotFound)
+		return
+	}
+	m.writeReport(w, report)
+}
+
+func (m *DiagnosticAPI) postDrift(w http.ResponseWriter, r *http.Request) {
+	repush := m.synchronizer.DriftRepush()
+	repushParam := r.URL.Query().Get("repush")
+	if repushParam != "" {
+		var err error
+		repush, err = strconv.ParseBool(repushParam)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid repush parameter %s", repushParam), http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := m.synchronizer.DriftCheck(repush)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	m.writeReport(w, report)
+}
+
+func (m *DiagnosticAPI) getImpact(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	queryArgs := r.URL.Query()
+
+	node, err := refgraph.NewNodeID(refgraph.NodeKind(vars["kind"]), queryArgs.Get("enterprise"), queryArgs.Get("site"), vars["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	impact, err := m.synchronizer.Impact(node)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	m.writeReport(w, impact)
+}
+
+func (m *DiagnosticAPI) getEffectiveSlices(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	slices, err := m.synchronizer.GetEffectiveSlices(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if (id != "") && (len(slices) == 0) {
+		http.Error(w, fmt.Sprintf("no slice %s", id), http.StatusNotFound)
+		return
+	}
+	m.writeReport(w, slices)
+}
+
+func (m *DiagnosticAPI) getValidate(w http.ResponseWriter, r *http.Request) {
+	_ = r
+	jsonDump, err := m.targetServer.GetJSON()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	findings, err := validation.ValidateJSON(jsonDump)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	m.writeReport(w, findings)
+}
+
+func (m *DiagnosticAPI) postValidate(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	findings, err := validation.ValidateJSON(reqBody)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	m.writeReport(w, findings)
+}
+
 // this method is not exported in onos logger
 func splitLoggerName(name string) []string {
 	names := strings.Split(name, "/")
@@ -221,15 +461,30 @@ func (m *DiagnosticAPI) handleRequests(port uint) {
 	myRouter.HandleFunc("/pull", m.pullFromOnosConfig).Methods("POST")
 	myRouter.HandleFunc("/loglevel/{logger}", m.getLogLevel).Methods("GET")
 	myRouter.HandleFunc("/loglevel/{logger}", m.setLogLevel).Methods("POST")
+	myRouter.HandleFunc("/plan", m.getPlan).Methods("GET")
+	myRouter.HandleFunc("/status", m.getStatus).Methods("GET")
+	myRouter.HandleFunc("/status/{kind}", m.getStatus).Methods("GET")
+	myRouter.HandleFunc("/status/{kind}/{id}", m.getStatus).Methods("GET")
+	myRouter.HandleFunc("/reconcile", m.getReconcile).Methods("GET")
+	myRouter.HandleFunc("/reconcile", m.postReconcile).Methods("POST")
+	myRouter.HandleFunc("/drift", m.getDrift).Methods("GET")
+	myRouter.HandleFunc("/drift", m.postDrift).Methods("POST")
+	myRouter.HandleFunc("/impact/{kind}/{id}", m.getImpact).Methods("GET")
+	myRouter.HandleFunc("/effective-slices", m.getEffectiveSlices).Methods("GET")
+	myRouter.HandleFunc("/effective-slices/{id}", m.getEffectiveSlices).Methods("GET")
+	myRouter.HandleFunc("/validate", m.getValidate).Methods("GET")
+	myRouter.HandleFunc("/validate", m.postValidate).Methods("POST")
 	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), myRouter))
 }
 
 // StartDiagnosticAPI starts the Diagnostic API, serving requests
 func StartDiagnosticAPI(targetServer TargetInterface,
+	sync SynchronizerInterface,
 	defaultAetherConfigAddr string,
 	defaultTarget string,
 	port uint) {
 	m := DiagnosticAPI{targetServer: targetServer,
+		synchronizer:            sync,
 		defaultAetherConfigAddr: defaultAetherConfigAddr,
 		defaultTarget:           defaultTarget}
 	go m.handleRequests(port)
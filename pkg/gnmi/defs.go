@@ -7,6 +7,7 @@ package gnmi
 
 import (
 	"sync"
+	"time"
 
 	"github.com/eapache/channels"
 	"github.com/onosproject/onos-lib-go/pkg/logging"
@@ -45,6 +46,11 @@ func (c ConfigCallbackType) String() string {
 // ConfigCallback is the signature of the function to apply a validated config to the physical device.
 type ConfigCallback func(ygot.ValidatedGoStruct, ConfigCallbackType, *pb.Path) error
 
+// ConfigValidator is the signature of the function that checks a config before a Set is
+// committed. The config has already passed schema validation; the validator checks rules
+// that the schema cannot express.
+type ConfigValidator func(ygot.ValidatedGoStruct) error
+
 var (
 	pbRootPath         = &pb.Path{}
 	supportedEncodings = []pb.Encoding{pb.Encoding_JSON, pb.Encoding_JSON_IETF}
@@ -71,12 +77,21 @@ var (
 type Server struct {
 	model        *Model
 	callback     ConfigCallback
+	validator    ConfigValidator
 	config       ygot.ValidatedGoStruct
 	ConfigUpdate *channels.RingChannel
 	mu           sync.RWMutex // mu is the RW lock to protect the access to config
 	subscribed   map[string][]*streamClient
+
+	// optional persistent store of the config
+	store            *ConfigStore
+	snapshotInterval time.Duration
+	stopSnapshots    chan struct{}
 }
 
+// ServerOption is for options passed when creating a new server
+type ServerOption func(s *Server)
+
 var (
 	lowestSampleInterval uint64 = 5000000000 // 5000000000 nanoseconds
 )
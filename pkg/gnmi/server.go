@@ -7,24 +7,62 @@ package gnmi
 
 import (
 	"encoding/json"
+	"fmt"
+	"time"
+
 	"github.com/eapache/channels"
 	pb "github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/ygot/ygot"
 )
 
+// WithConfigStore persists the config to the given store. A snapshot of the config is
+// written every snapshotInterval; zero disables periodic snapshots.
+func WithConfigStore(store *ConfigStore, snapshotInterval time.Duration) ServerOption {
+	return func(s *Server) {
+		s.store = store
+		s.snapshotInterval = snapshotInterval
+	}
+}
+
+// WithValidator checks the config resulting from each Set with validator before it is
+// applied. A Set that fails validation is rejected with InvalidArgument.
+func WithValidator(validator ConfigValidator) ServerOption {
+	return func(s *Server) {
+		s.validator = validator
+	}
+}
+
 // NewServer creates an instance of Server with given json config.
-func NewServer(model *Model, config []byte, callback ConfigCallback) (*Server, error) {
+func NewServer(model *Model, config []byte, callback ConfigCallback, opts ...ServerOption) (*Server, error) {
 	rootStruct, err := model.NewConfigStruct(config)
 	if err != nil {
 		return nil, err
 	}
 	s := &Server{
-		model:    model,
-		config:   rootStruct,
-		callback: callback,
+		model:  model,
+		config: rootStruct,
 	}
-	if config != nil && s.callback != nil {
-		if err := s.callback(rootStruct, Initial, nil); err != nil {
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	haveConfig := config != nil
+	if s.store != nil {
+		if haveConfig {
+			// Config that was passed to us explicitly supersedes whatever we stored
+			err = s.snapshot()
+		} else {
+			haveConfig, err = s.restore()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.callback = callback
+	if haveConfig && s.callback != nil {
+		if err := s.callback(s.config, Initial, nil); err != nil {
 			return nil, err
 		}
 	}
@@ -44,9 +82,79 @@ func NewServer(model *Model, config []byte, callback ConfigCallback) (*Server, e
 
 	s.ConfigUpdate = channels.NewRingChannel(100)
 
+	if (s.store != nil) && (s.snapshotInterval > 0) {
+		s.stopSnapshots = make(chan struct{})
+		go s.snapshotLoop()
+	}
+
 	return s, nil
 }
 
+// restore loads the config from the store, replaying any journaled Sets on top of
+// the last snapshot. Returns true if any stored config was found. Must be called
+// before the callback is set, so the replayed Sets are not pushed to the device.
+func (s *Server) restore() (bool, error) {
+	snapshot, requests, err := s.store.Load()
+	if err != nil {
+		return false, err
+	}
+
+	if snapshot == nil && len(requests) == 0 {
+		return false, nil
+	}
+
+	if snapshot != nil {
+		s.config, err = s.model.NewConfigStruct(snapshot)
+		if err != nil {
+			return false, fmt.Errorf("failed to restore snapshot: %v", err)
+		}
+	}
+
+	for _, req := range requests {
+		rootStruct, _, err := s.applySetRequest(req)
+		if err != nil {
+			// Every journaled Set succeeded originally, so this should not happen. Keep
+			// going with what we have rather than refusing to start.
+			log.Warnf("Failed to replay journaled Set: %v", err)
+			continue
+		}
+		s.config = rootStruct
+	}
+
+	log.Infof("Restored config from snapshot and %d journaled Sets", len(requests))
+
+	return true, nil
+}
+
+// snapshot writes a snapshot of the config to the store. Caller must hold s.mu or
+// otherwise ensure that the config is not changing.
+func (s *Server) snapshot() error {
+	data, err := s.configJSON()
+	if err != nil {
+		return err
+	}
+	return s.store.Snapshot(data)
+}
+
+// snapshotLoop periodically snapshots the config until the server is closed
+func (s *Server) snapshotLoop() {
+	ticker := time.NewTicker(s.snapshotInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stopSnapshots:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			err := s.snapshot()
+			s.mu.Unlock()
+			if err != nil {
+				log.Warnf("Failed to snapshot config: %v", err)
+			}
+		}
+	}
+}
+
 // Close - called on shutdown - shutdown gracefully
 func (s *Server) Close() {
 	log.Info("Shutting down gNMI server")
@@ -61,6 +169,20 @@ func (s *Server) Close() {
 		log.Info("Closing Ring Buffer Channel")
 		s.ConfigUpdate.Close()
 	}
+
+	if s.store != nil {
+		if s.stopSnapshots != nil {
+			close(s.stopSnapshots)
+		}
+		s.mu.Lock()
+		if err := s.snapshot(); err != nil {
+			log.Warnf("Failed to snapshot config: %v", err)
+		}
+		s.mu.Unlock()
+		if err := s.store.Close(); err != nil {
+			log.Warnf("Failed to close config store: %v", err)
+		}
+	}
 }
 
 // ExecuteCallbacks executes the callbacks for the synchronizer
@@ -78,6 +200,11 @@ func (s *Server) GetJSON() ([]byte, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	return s.configJSON()
+}
+
+// configJSON returns the JSON value of the config tree. Caller must hold s.mu.
+func (s *Server) configJSON() ([]byte, error) {
 	jsonTree, err := ygot.ConstructIETFJSON(s.config, &ygot.RFC7951JSONConfig{})
 	if err != nil {
 		return []byte{}, err
@@ -99,5 +226,9 @@ func (s *Server) PutJSON(b []byte) error {
 		return err
 	}
 	s.config = rootStruct
+
+	if s.store != nil {
+		return s.snapshot()
+	}
 	return nil
 }
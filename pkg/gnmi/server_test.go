@@ -6,6 +6,7 @@ package gnmi
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"io/ioutil"
@@ -231,6 +232,35 @@ func TestSet(t *testing.T) {
 	}
 }
 
+func TestSetValidator(t *testing.T) {
+	validatorCalls := 0
+	validator := func(config ygot.ValidatedGoStruct) error {
+		validatorCalls++
+		return fmt.Errorf("ip-domain ip-domain-demo-1 is not allowed")
+	}
+
+	s, err := NewServer(model, []byte(`{}`), nil, WithValidator(validator))
+	require.NoError(t, err)
+
+	textPbPrefix := `
+		elem: <name: 'enterprises'>
+		elem: <name: 'enterprise' key: <key: 'enterprise-id' value: 'acme'>>
+		elem: <name: 'site' key: <key: 'site-id' value: 'acme-site'>>
+		elem: <name: 'ip-domain' key: <key: 'ip-domain-id' value: 'ip-domain-demo-1'>>
+		`
+	textPbUpdate := `
+		path: <elem: <name: 'dns-primary'>>
+		val: <string_val: '8.8.8.1'>
+		`
+	runTestSet(t, s, textPbPrefix, textPbUpdate, codes.InvalidArgument, nil)
+	assert.Equal(t, 1, validatorCalls)
+
+	// The rejected Set was not applied
+	jsonDump, err := s.GetJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(jsonDump))
+}
+
 // runTestGet requests a path from the server by Get grpc call, and compares if
 // the return code and response value are expected.
 func runTestSet(t *testing.T, s *Server, textPbPrefix string, textPbUpdate string, wantRetCode codes.Code, useModels []*pb.ModelData) {
@@ -172,20 +172,15 @@ func (s *Server) doReplaceOrUpdate(jsonTree map[string]interface{}, op pb.Update
 	}, nil
 }
 
-// Set implements the Set RPC in gNMI spec.
-func (s *Server) Set(req *pb.SetRequest) (*pb.SetResponse, error) {
-	tStart := time.Now()
-	gnmiRequestsTotal.WithLabelValues("SET").Inc()
-
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
+// applySetRequest applies the deletes, replaces, and updates in a SetRequest to a copy of
+// the config tree, and returns the resulting config. The server's config is not modified.
+// Caller must hold s.mu.
+func (s *Server) applySetRequest(req *pb.SetRequest) (ygot.ValidatedGoStruct, []*pb.UpdateResult, error) {
 	jsonTree, err := ygot.ConstructIETFJSON(s.config, &ygot.RFC7951JSONConfig{})
 	if err != nil {
 		msg := fmt.Sprintf("error in constructing IETF JSON tree from config struct: %v", err)
 		log.Error(msg)
-		gnmiRequestsFailedTotal.WithLabelValues("SET").Inc()
-		return nil, status.Error(codes.Internal, msg)
+		return nil, nil, status.Error(codes.Internal, msg)
 	}
 
 	prefix := req.GetPrefix()
@@ -196,8 +191,7 @@ func (s *Server) Set(req *pb.SetRequest) (*pb.SetResponse, error) {
 		res, _, grpcStatusError := s.doDelete(jsonTree, prefix, path)
 		if grpcStatusError != nil {
 			log.Warnf("Delete returning with error %v", grpcStatusError)
-			gnmiRequestsFailedTotal.WithLabelValues("SET").Inc()
-			return nil, grpcStatusError
+			return nil, nil, grpcStatusError
 		}
 		results = append(results, res)
 	}
@@ -205,9 +199,8 @@ func (s *Server) Set(req *pb.SetRequest) (*pb.SetResponse, error) {
 		log.Debugf("Handling replace: %v", upd)
 		res, grpcStatusError := s.doReplaceOrUpdate(jsonTree, pb.UpdateResult_REPLACE, prefix, upd.GetPath(), upd.GetVal())
 		if grpcStatusError != nil {
-			gnmiRequestsFailedTotal.WithLabelValues("SET").Inc()
 			log.Warnf("Replace returning with error %v", grpcStatusError)
-			return nil, grpcStatusError
+			return nil, nil, grpcStatusError
 		}
 		results = append(results, res)
 	}
@@ -215,9 +208,8 @@ func (s *Server) Set(req *pb.SetRequest) (*pb.SetResponse, error) {
 		log.Debugf("Handling update: %v", upd)
 		res, grpcStatusError := s.doReplaceOrUpdate(jsonTree, pb.UpdateResult_UPDATE, prefix, upd.GetPath(), upd.GetVal())
 		if grpcStatusError != nil {
-			gnmiRequestsFailedTotal.WithLabelValues("SET").Inc()
 			log.Warnf("Update returning with error %v", grpcStatusError)
-			return nil, grpcStatusError
+			return nil, nil, grpcStatusError
 		}
 		results = append(results, res)
 	}
@@ -226,24 +218,65 @@ func (s *Server) Set(req *pb.SetRequest) (*pb.SetResponse, error) {
 	if err != nil {
 		msg := fmt.Sprintf("error in marshaling IETF JSON tree to bytes: %v", err)
 		log.Error(msg)
-		gnmiRequestsFailedTotal.WithLabelValues("SET").Inc()
-		return nil, status.Error(codes.Internal, msg)
+		return nil, nil, status.Error(codes.Internal, msg)
 	}
 
 	rootStruct, err := s.model.NewConfigStruct(jsonDump)
 	if err != nil {
 		msg := fmt.Sprintf("error in creating config struct from IETF JSON data: %v", err)
 		log.Error(msg)
+		return nil, nil, status.Error(codes.Internal, msg)
+	}
+
+	return rootStruct, results, nil
+}
+
+// setRequestPath returns the common prefix of the full paths of every delete, replace, and
+// update in a SetRequest
+func setRequestPath(req *pb.SetRequest) *pb.Path {
+	prefix := req.GetPrefix()
+	paths := []*pb.Path{}
+	for _, path := range req.GetDelete() {
+		paths = append(paths, gnmiFullPath(prefix, path))
+	}
+	for _, upd := range req.GetReplace() {
+		paths = append(paths, gnmiFullPath(prefix, upd.GetPath()))
+	}
+	for _, upd := range req.GetUpdate() {
+		paths = append(paths, gnmiFullPath(prefix, upd.GetPath()))
+	}
+	return CommonPathPrefix(paths)
+}
+
+// Set implements the Set RPC in gNMI spec.
+func (s *Server) Set(req *pb.SetRequest) (*pb.SetResponse, error) {
+	tStart := time.Now()
+	gnmiRequestsTotal.WithLabelValues("SET").Inc()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rootStruct, results, err := s.applySetRequest(req)
+	if err != nil {
 		gnmiRequestsFailedTotal.WithLabelValues("SET").Inc()
-		return nil, status.Error(codes.Internal, msg)
+		return nil, err
+	}
+
+	if s.validator != nil {
+		if validateErr := s.validator(rootStruct); validateErr != nil {
+			gnmiRequestsFailedTotal.WithLabelValues("SET").Inc()
+			log.Warnf("Set rejected by validator: %v", validateErr)
+			return nil, status.Errorf(codes.InvalidArgument, "config validation fails: %v", validateErr)
+		}
 	}
 
 	// Apply the validated operation to the device.
 	// Note: We apply this after all operations have been applied to the config tree, because it is
 	// more performant to the json.Marshal and NewConfigStruct once per gnmi operation than it is to
-	// do it for each individual path set or delete.
+	// do it for each individual path set or delete. The callback is given the deepest path that
+	// contains everything the request touched, so that it can limit its work to that subtree.
 	if s.callback != nil {
-		if applyErr := s.callback(rootStruct, Apply, nil); applyErr != nil {
+		if applyErr := s.callback(rootStruct, Apply, setRequestPath(req)); applyErr != nil {
 			if rollbackErr := s.callback(s.config, Rollback, nil); rollbackErr != nil {
 				return nil, status.Errorf(codes.Internal, "error in rollback the failed operation (%v): %v", applyErr, rollbackErr)
 			}
@@ -251,6 +284,19 @@ func (s *Server) Set(req *pb.SetRequest) (*pb.SetResponse, error) {
 		}
 	}
 
+	// Record the Set before we acknowledge it, so that it survives a restart
+	if s.store != nil {
+		if storeErr := s.store.Append(req); storeErr != nil {
+			gnmiRequestsFailedTotal.WithLabelValues("SET").Inc()
+			if s.callback != nil {
+				if rollbackErr := s.callback(s.config, Rollback, nil); rollbackErr != nil {
+					return nil, status.Errorf(codes.Internal, "error in rollback the failed operation (%v): %v", storeErr, rollbackErr)
+				}
+			}
+			return nil, status.Errorf(codes.Internal, "error in storing operation: %v", storeErr)
+		}
+	}
+
 	s.config = rootStruct
 
 	setResponse := &pb.SetResponse{
@@ -0,0 +1,240 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package gnmi implements a gnmi server to mock a device with YANG models.
+package gnmi
+
+/*
+ * ConfigStore: an optional on-disk store for the configuration held by the Server.
+ *
+ * The store consists of two files:
+ *    snapshot -- the full configuration tree as of some sequence number
+ *    journal  -- one line per successful Set, each tagged with a sequence number
+ *
+ * Every successful Set is appended to the journal and synced before the Set returns. A
+ * snapshot is written periodically (and whenever the whole tree is replaced). The snapshot
+ * is written atomically, and then the journal is truncated. On startup, the snapshot is
+ * loaded and any journal entries with a sequence number greater than the snapshot's are
+ * replayed on top of it. If we crash between writing the snapshot and truncating the
+ * journal, the sequence numbers ensure that nothing is applied twice.
+ */
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/golang/protobuf/jsonpb" //nolint: staticcheck
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+const (
+	// ConfigSnapshotFileName is the name of the snapshot file within the store directory
+	ConfigSnapshotFileName = "config-snapshot.json"
+
+	// ConfigJournalFileName is the name of the journal file within the store directory
+	ConfigJournalFileName = "config-journal.jsonl"
+)
+
+// ConfigStore persists the configuration of a Server to a directory
+type ConfigStore struct {
+	dir      string
+	mu       sync.Mutex
+	journal  *os.File
+	sequence uint64
+}
+
+type configSnapshot struct {
+	Sequence uint64          `json:"sequence"`
+	Config   json.RawMessage `json:"config"`
+}
+
+type configJournalEntry struct {
+	Sequence uint64          `json:"sequence"`
+	Request  json.RawMessage `json:"request"`
+}
+
+// NewConfigStore creates a ConfigStore in the given directory, creating the directory
+// if necessary.
+func NewConfigStore(dir string) (*ConfigStore, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConfigStore{dir: dir}, nil
+}
+
+func (c *ConfigStore) snapshotFileName() string {
+	return filepath.Join(c.dir, ConfigSnapshotFileName)
+}
+
+func (c *ConfigStore) journalFileName() string {
+	return filepath.Join(c.dir, ConfigJournalFileName)
+}
+
+// Load returns the last snapshot and the Set requests that were journaled after it.
+// The snapshot is nil if no snapshot has been written. After Load returns, the store
+// is ready to accept new journal entries.
+func (c *ConfigStore) Load() ([]byte, []*pb.SetRequest, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var config []byte
+	snapshotSequence := uint64(0)
+
+	data, err := ioutil.ReadFile(c.snapshotFileName())
+	if err == nil {
+		snapshot := configSnapshot{}
+		err = json.Unmarshal(data, &snapshot)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse snapshot %s: %v", c.snapshotFileName(), err)
+		}
+		config = snapshot.Config
+		snapshotSequence = snapshot.Sequence
+	} else if !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+	c.sequence = snapshotSequence
+
+	requests := []*pb.SetRequest{}
+
+	f, err := os.Open(c.journalFileName())
+	if err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+		for scanner.Scan() {
+			entry := configJournalEntry{}
+			req := &pb.SetRequest{}
+			err = json.Unmarshal(scanner.Bytes(), &entry)
+			if err == nil {
+				err = jsonpb.UnmarshalString(string(entry.Request), req)
+			}
+			if err != nil {
+				// Most likely we crashed in the middle of writing this entry. Since the
+				// write never completed, the Set never returned success.
+				log.Warnf("Stopping journal replay at unreadable entry: %v", err)
+				break
+			}
+			if entry.Sequence <= snapshotSequence {
+				// already included in the snapshot
+				continue
+			}
+			requests = append(requests, req)
+			c.sequence = entry.Sequence
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+
+	return config, requests, nil
+}
+
+// openJournal opens the journal for appending. Caller must hold c.mu.
+func (c *ConfigStore) openJournal() error {
+	if c.journal != nil {
+		return nil
+	}
+	f, err := os.OpenFile(c.journalFileName(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	c.journal = f
+	return nil
+}
+
+// Append adds a Set request to the journal, and does not return until it is on disk
+func (c *ConfigStore) Append(req *pb.SetRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	reqJSON, err := (&jsonpb.Marshaler{}).MarshalToString(req)
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(configJournalEntry{Sequence: c.sequence + 1, Request: json.RawMessage(reqJSON)})
+	if err != nil {
+		return err
+	}
+
+	err = c.openJournal()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.journal.Write(append(line, '\n'))
+	if err != nil {
+		return err
+	}
+
+	err = c.journal.Sync()
+	if err != nil {
+		return err
+	}
+
+	c.sequence++
+
+	return nil
+}
+
+// Snapshot saves the full configuration, and then discards the journal entries that it
+// supersedes.
+func (c *ConfigStore) Snapshot(config []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.Marshal(configSnapshot{Sequence: c.sequence, Config: json.RawMessage(config)})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(c.dir, "."+ConfigSnapshotFileName+".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.snapshotFileName())
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	// The snapshot is durable, so the journal may be truncated
+	err = c.openJournal()
+	if err != nil {
+		return err
+	}
+	return c.journal.Truncate(0)
+}
+
+// Close closes the journal
+func (c *ConfigStore) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.journal == nil {
+		return nil
+	}
+	err := c.journal.Close()
+	c.journal = nil
+	return err
+}
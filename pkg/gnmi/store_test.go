@@ -0,0 +1,141 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+package gnmi
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/protobuf/proto" //nolint: staticcheck
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const storeTestPrefix = `
+	elem: <name: 'enterprises'>
+	elem: <name: 'enterprise' key: <key:'enterprise-id', value:'acme'>>
+	elem: <name: 'site' key: <key:'site-id', value:'acme-site'>>
+	elem: <name: 'ip-domain' key:<key:'ip-domain-id' value:'ip-domain-demo-1'>>
+`
+
+func storeTestSetRequest(t *testing.T, dns string) *pb.SetRequest {
+	var pbPrefix pb.Path
+	err := proto.UnmarshalText(storeTestPrefix, &pbPrefix)
+	assert.NoError(t, err)
+	return &pb.SetRequest{
+		Prefix: &pbPrefix,
+		Update: []*pb.Update{{
+			Path: &pb.Path{Elem: []*pb.PathElem{{Name: "dns-primary"}}},
+			Val:  &pb.TypedValue{Value: &pb.TypedValue_StringVal{StringVal: dns}},
+		}},
+	}
+}
+
+func TestConfigStoreRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gnmi-store")
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(dir))
+	}()
+
+	store, err := NewConfigStore(dir)
+	assert.NoError(t, err)
+	s, err := NewServer(model, nil, nil, WithConfigStore(store, 0))
+	assert.NoError(t, err)
+
+	_, err = s.Set(storeTestSetRequest(t, "8.8.8.1"))
+	assert.NoError(t, err)
+	_, err = s.Set(storeTestSetRequest(t, "8.8.8.2"))
+	assert.NoError(t, err)
+	wantJSON, err := s.GetJSON()
+	assert.NoError(t, err)
+	assert.Contains(t, string(wantJSON), "8.8.8.2")
+
+	// Restart without closing; the journal alone must be enough
+	restoredCalls := 0
+	callback := func(config ygot.ValidatedGoStruct, callbackType ConfigCallbackType, path *pb.Path) error {
+		assert.Equal(t, Initial, callbackType)
+		restoredCalls++
+		return nil
+	}
+	store2, err := NewConfigStore(dir)
+	assert.NoError(t, err)
+	s2, err := NewServer(model, nil, callback, WithConfigStore(store2, 0))
+	assert.NoError(t, err)
+	gotJSON, err := s2.GetJSON()
+	assert.NoError(t, err)
+	require.JSONEq(t, string(wantJSON), string(gotJSON))
+	assert.Equal(t, 1, restoredCalls)
+
+	// Close takes a snapshot and truncates the journal
+	s2.Close()
+	journal, err := ioutil.ReadFile(filepath.Join(dir, ConfigJournalFileName))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(journal))
+
+	store3, err := NewConfigStore(dir)
+	assert.NoError(t, err)
+	s3, err := NewServer(model, nil, nil, WithConfigStore(store3, 0))
+	assert.NoError(t, err)
+	gotJSON, err = s3.GetJSON()
+	assert.NoError(t, err)
+	require.JSONEq(t, string(wantJSON), string(gotJSON))
+}
+
+func TestConfigStoreTruncatedJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gnmi-store")
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(dir))
+	}()
+
+	store, err := NewConfigStore(dir)
+	assert.NoError(t, err)
+	s, err := NewServer(model, nil, nil, WithConfigStore(store, 0))
+	assert.NoError(t, err)
+	_, err = s.Set(storeTestSetRequest(t, "8.8.8.1"))
+	assert.NoError(t, err)
+
+	// Simulate a crash in the middle of writing the next entry
+	f, err := os.OpenFile(filepath.Join(dir, ConfigJournalFileName), os.O_WRONLY|os.O_APPEND, 0644)
+	assert.NoError(t, err)
+	_, err = f.WriteString(`{"sequence":2,"request":{"prefix":`)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	store2, err := NewConfigStore(dir)
+	assert.NoError(t, err)
+	s2, err := NewServer(model, nil, nil, WithConfigStore(store2, 0))
+	assert.NoError(t, err)
+	gotJSON, err := s2.GetJSON()
+	assert.NoError(t, err)
+	assert.Contains(t, string(gotJSON), "8.8.8.1")
+}
+
+func TestConfigStoreExplicitConfigWins(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gnmi-store")
+	assert.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(dir))
+	}()
+
+	store, err := NewConfigStore(dir)
+	assert.NoError(t, err)
+	s, err := NewServer(model, nil, nil, WithConfigStore(store, 0))
+	assert.NoError(t, err)
+	_, err = s.Set(storeTestSetRequest(t, "8.8.8.1"))
+	assert.NoError(t, err)
+
+	store2, err := NewConfigStore(dir)
+	assert.NoError(t, err)
+	s2, err := NewServer(model, []byte("{}"), nil, WithConfigStore(store2, 0))
+	assert.NoError(t, err)
+	gotJSON, err := s2.GetJSON()
+	assert.NoError(t, err)
+	assert.NotContains(t, string(gotJSON), "8.8.8.1")
+}
@@ -183,6 +183,31 @@ func gnmiFullPath(prefix, path *pb.Path) *pb.Path {
 	return fullPath
 }
 
+// CommonPathPrefix returns the longest path that is a prefix of every path, comparing both
+// names and keys. It returns nil if there are no paths.
+func CommonPathPrefix(paths []*pb.Path) *pb.Path {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	prefix := &pb.Path{Elem: paths[0].GetElem()}
+	for _, path := range paths[1:] {
+		elems := path.GetElem()
+		n := 0
+		for (n < len(prefix.Elem)) && (n < len(elems)) {
+			if (prefix.Elem[n].Name != elems[n].Name) || !reflect.DeepEqual(prefix.Elem[n].Key, elems[n].Key) {
+				break
+			}
+			n++
+		}
+		prefix.Elem = prefix.Elem[:n]
+	}
+
+	// Don't share the backing array with the caller's path
+	prefix.Elem = append([]*pb.PathElem{}, prefix.Elem...)
+	return prefix
+}
+
 // PathToString converts a gnmi path to a human-readable string
 func PathToString(path *pb.Path) string {
 	if path == nil {
@@ -66,3 +66,19 @@ func TestGetChildNode(t *testing.T) {
 	assert.Nil(t, err)
 	assert.JSONEq(t, `{"cont1a": {"cont2d": {"pretzel": {}}}}`, string(jsonStr))
 }
+
+func TestCommonPathPrefix(t *testing.T) {
+	assert.Nil(t, CommonPathPrefix(nil))
+
+	ent := &pb.PathElem{Name: "enterprise", Key: map[string]string{"enterprise-id": "ent1"}}
+	site1 := &pb.PathElem{Name: "site", Key: map[string]string{"site-id": "site1"}}
+	site2 := &pb.PathElem{Name: "site", Key: map[string]string{"site-id": "site2"}}
+	path1 := &pb.Path{Elem: []*pb.PathElem{{Name: "enterprises"}, ent, site1, {Name: "description"}}}
+	path2 := &pb.Path{Elem: []*pb.PathElem{{Name: "enterprises"}, ent, site1, {Name: "display-name"}}}
+	path3 := &pb.Path{Elem: []*pb.PathElem{{Name: "enterprises"}, ent, site2}}
+
+	assert.Equal(t, "enterprises/enterprise[enterprise-id=ent1]/site[site-id=site1]/description", PathToString(CommonPathPrefix([]*pb.Path{path1})))
+	assert.Equal(t, "enterprises/enterprise[enterprise-id=ent1]/site[site-id=site1]", PathToString(CommonPathPrefix([]*pb.Path{path1, path2})))
+	assert.Equal(t, "enterprises/enterprise[enterprise-id=ent1]", PathToString(CommonPathPrefix([]*pb.Path{path1, path2, path3})))
+	assert.Empty(t, CommonPathPrefix([]*pb.Path{path1, {}}).Elem)
+}
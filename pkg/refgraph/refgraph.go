@@ -0,0 +1,494 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package refgraph builds a graph of the references between the objects of an Aether 2.0
+// model, so that the impact of changing or deleting an object can be found.
+package refgraph
+
+import (
+	"fmt"
+	"sort"
+
+	models "github.com/onosproject/aether-models/models/aether-2.0.x/api"
+)
+
+// NodeKind is the kind of object a node represents
+type NodeKind string
+
+// The kinds of object in the graph. Each is named after its list in the model.
+const (
+	KindConnectivityService NodeKind = "connectivity-service"
+	KindEnterprise          NodeKind = "enterprise"
+	KindApplication         NodeKind = "application"
+	KindTrafficClass        NodeKind = "traffic-class"
+	KindTemplate            NodeKind = "template"
+	KindSite                NodeKind = "site"
+	KindDeviceGroup         NodeKind = "device-group"
+	KindDevice              NodeKind = "device"
+	KindSimCard             NodeKind = "sim-card"
+	KindIPDomain            NodeKind = "ip-domain"
+	KindSlice               NodeKind = "slice"
+	KindSmallCell           NodeKind = "small-cell"
+	KindUpf                 NodeKind = "upf"
+)
+
+// enterpriseKinds are the kinds that are listed directly in an enterprise
+var enterpriseKinds = map[NodeKind]bool{
+	KindApplication:  true,
+	KindTrafficClass: true,
+	KindTemplate:     true,
+	KindSite:         true,
+}
+
+// siteKinds are the kinds that are listed in a site
+var siteKinds = map[NodeKind]bool{
+	KindDeviceGroup: true,
+	KindDevice:      true,
+	KindSimCard:     true,
+	KindIPDomain:    true,
+	KindSlice:       true,
+	KindSmallCell:   true,
+	KindUpf:         true,
+}
+
+// NodeID identifies an object in the model. Enterprise and Site are the scope the object is
+// listed in, and are empty for objects that are not listed in one.
+type NodeID struct {
+	Kind       NodeKind `json:"kind"`
+	Enterprise string   `json:"enterprise,omitempty"`
+	Site       string   `json:"site,omitempty"`
+	ID         string   `json:"id"`
+}
+
+func (n NodeID) String() string {
+	switch {
+	case n.Site != "":
+		return fmt.Sprintf("%s %s/%s/%s", n.Kind, n.Enterprise, n.Site, n.ID)
+	case n.Enterprise != "":
+		return fmt.Sprintf("%s %s/%s", n.Kind, n.Enterprise, n.ID)
+	}
+	return fmt.Sprintf("%s %s", n.Kind, n.ID)
+}
+
+// NewNodeID returns the id of an object of kind, scoped to an enterprise and site as the
+// kind requires. Scopes that the kind does not use are ignored.
+func NewNodeID(kind NodeKind, enterprise string, site string, id string) (NodeID, error) {
+	switch {
+	case kind == KindConnectivityService:
+		return NodeID{Kind: kind, ID: id}, nil
+	case kind == KindEnterprise:
+		return NodeID{Kind: kind, ID: id}, nil
+	case enterpriseKinds[kind]:
+		if enterprise == "" {
+			return NodeID{}, fmt.Errorf("%s %s requires an enterprise", kind, id)
+		}
+		return NodeID{Kind: kind, Enterprise: enterprise, ID: id}, nil
+	case siteKinds[kind]:
+		if (enterprise == "") || (site == "") {
+			return NodeID{}, fmt.Errorf("%s %s requires an enterprise and a site", kind, id)
+		}
+		return NodeID{Kind: kind, Enterprise: enterprise, Site: site, ID: id}, nil
+	}
+	return NodeID{}, fmt.Errorf("Unknown kind %s", kind)
+}
+
+// Southbound is an object that the synchronizer pushes to a connectivity service's core.
+// A slice with a UPF is also pushed to the UPF.
+type Southbound struct {
+	Kind                NodeKind `json:"kind"`
+	ID                  string   `json:"id"`
+	Enterprise          string   `json:"enterprise"`
+	Site                string   `json:"site"`
+	ConnectivityService string   `json:"connectivity-service"`
+	Upf                 string   `json:"upf,omitempty"`
+}
+
+// Reference is a reference from one node to another
+type Reference struct {
+	From NodeID `json:"from"`
+	To   NodeID `json:"to"`
+}
+
+// Impact is the result of analyzing a change to, or delete of, one node
+type Impact struct {
+	Node NodeID `json:"node"`
+	// Referrers are the nodes that refer to Node directly, not counting the objects it
+	// contains. Deleting Node leaves their references dangling.
+	Referrers []NodeID `json:"referrers"`
+	// Dependents are all the nodes that refer to or are contained in Node, directly or
+	// through other nodes
+	Dependents []NodeID `json:"dependents"`
+	// Southbound are the pushed objects that would change if Node did
+	Southbound []Southbound `json:"southbound"`
+}
+
+// Graph holds the references between the objects of a model. An edge from a node to one it
+// uses means that the node depends on it; an object also depends on the object that
+// contains it.
+type Graph struct {
+	nodes          map[NodeID]bool
+	uses           map[NodeID]map[NodeID]bool
+	usedBy         map[NodeID]map[NodeID]bool
+	contains       map[NodeID]map[NodeID]bool
+	upfs           map[NodeID]string
+	csByEnterprise map[string][]string
+	standbyUpfs    map[string][]string
+	sliceTemplates *SliceTemplates
+}
+
+// BuildOption sets an option for building a Graph
+type BuildOption func(g *Graph)
+
+// WithSliceTemplates sets the templates that slices inherit their unset fields from. A
+// slice depends on its template.
+func WithSliceTemplates(sliceTemplates *SliceTemplates) BuildOption {
+	return func(g *Graph) {
+		g.sliceTemplates = sliceTemplates
+	}
+}
+
+// WithStandbyUpfs sets the standby UPFs of each UPF, by UPF ID. A slice depends on the
+// standbys of its UPF that are in its site, as it is pushed to them too.
+func WithStandbyUpfs(standbyUpfs map[string][]string) BuildOption {
+	return func(g *Graph) {
+		g.standbyUpfs = standbyUpfs
+	}
+}
+
+func newGraph() *Graph {
+	return &Graph{
+		nodes:          map[NodeID]bool{},
+		uses:           map[NodeID]map[NodeID]bool{},
+		usedBy:         map[NodeID]map[NodeID]bool{},
+		contains:       map[NodeID]map[NodeID]bool{},
+		upfs:           map[NodeID]string{},
+		csByEnterprise: map[string][]string{},
+	}
+}
+
+func (g *Graph) addNode(node NodeID) {
+	g.nodes[node] = true
+}
+
+// addEdge records that from depends on to
+func (g *Graph) addEdge(from NodeID, to NodeID) {
+	if g.uses[from] == nil {
+		g.uses[from] = map[NodeID]bool{}
+	}
+	g.uses[from][to] = true
+	if g.usedBy[to] == nil {
+		g.usedBy[to] = map[NodeID]bool{}
+	}
+	g.usedBy[to][from] = true
+}
+
+// addChild records that parent contains child
+func (g *Graph) addChild(parent NodeID, child NodeID) {
+	g.addNode(child)
+	g.addEdge(child, parent)
+	if g.contains[parent] == nil {
+		g.contains[parent] = map[NodeID]bool{}
+	}
+	g.contains[parent][child] = true
+}
+
+// Build builds the reference graph of a device. References to objects that are not in the
+// device are recorded, so that the graph can be used to find what a missing object breaks.
+func Build(device *models.Device, opts ...BuildOption) *Graph {
+	g := newGraph()
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	if device.ConnectivityServices != nil {
+		for csID := range device.ConnectivityServices.ConnectivityService {
+			g.addNode(NodeID{Kind: KindConnectivityService, ID: csID})
+		}
+	}
+
+	if device.Enterprises == nil {
+		return g
+	}
+
+	for entID, enterprise := range device.Enterprises.Enterprise {
+		entNode := NodeID{Kind: KindEnterprise, ID: entID}
+		g.addNode(entNode)
+
+		for csID := range enterprise.ConnectivityService {
+			csNode := NodeID{Kind: KindConnectivityService, ID: csID}
+			g.addEdge(entNode, csNode)
+			// Objects are only pushed to connectivity services that exist
+			if g.nodes[csNode] {
+				g.csByEnterprise[entID] = append(g.csByEnterprise[entID], csID)
+			}
+		}
+		sort.Strings(g.csByEnterprise[entID])
+
+		entChild := func(kind NodeKind, id string) NodeID {
+			return NodeID{Kind: kind, Enterprise: entID, ID: id}
+		}
+
+		for tcID := range enterprise.TrafficClass {
+			g.addChild(entNode, entChild(KindTrafficClass, tcID))
+		}
+		for tpID := range enterprise.Template {
+			g.addChild(entNode, entChild(KindTemplate, tpID))
+		}
+		for appID, app := range enterprise.Application {
+			appNode := entChild(KindApplication, appID)
+			g.addChild(entNode, appNode)
+			for _, endpoint := range app.Endpoint {
+				if endpoint.TrafficClass != nil {
+					g.addEdge(appNode, entChild(KindTrafficClass, *endpoint.TrafficClass))
+				}
+			}
+		}
+
+		for siteID, site := range enterprise.Site {
+			g.buildSite(entNode, entChild(KindSite, siteID), site)
+			// A slice inherits its unset fields from its template
+			for sliceID := range site.Slice {
+				if tpID := g.sliceTemplates.Template(enterprise, sliceID); tpID != "" {
+					g.addEdge(NodeID{Kind: KindSlice, Enterprise: entID, Site: siteID, ID: sliceID}, entChild(KindTemplate, tpID))
+				}
+			}
+		}
+	}
+
+	return g
+}
+
+// buildSite adds a site and the objects in it to the graph
+func (g *Graph) buildSite(entNode NodeID, siteNode NodeID, site *models.OnfEnterprise_Enterprises_Enterprise_Site) {
+	entID := entNode.ID
+	g.addChild(entNode, siteNode)
+
+	siteChild := func(kind NodeKind, id string) NodeID {
+		return NodeID{Kind: kind, Enterprise: entID, Site: siteNode.ID, ID: id}
+	}
+
+	for ipdID := range site.IpDomain {
+		g.addChild(siteNode, siteChild(KindIPDomain, ipdID))
+	}
+	for simID := range site.SimCard {
+		g.addChild(siteNode, siteChild(KindSimCard, simID))
+	}
+	for upfID := range site.Upf {
+		g.addChild(siteNode, siteChild(KindUpf, upfID))
+	}
+	for scID := range site.SmallCell {
+		g.addChild(siteNode, siteChild(KindSmallCell, scID))
+	}
+	for devID, dev := range site.Device {
+		devNode := siteChild(KindDevice, devID)
+		g.addChild(siteNode, devNode)
+		if dev.SimCard != nil {
+			g.addEdge(devNode, siteChild(KindSimCard, *dev.SimCard))
+		}
+	}
+	for dgID, dg := range site.DeviceGroup {
+		dgNode := siteChild(KindDeviceGroup, dgID)
+		g.addChild(siteNode, dgNode)
+		if dg.IpDomain != nil {
+			g.addEdge(dgNode, siteChild(KindIPDomain, *dg.IpDomain))
+		}
+		if dg.TrafficClass != nil {
+			g.addEdge(dgNode, NodeID{Kind: KindTrafficClass, Enterprise: entID, ID: *dg.TrafficClass})
+		}
+		for _, dgDevice := range dg.Device {
+			if dgDevice.DeviceId != nil {
+				g.addEdge(dgNode, siteChild(KindDevice, *dgDevice.DeviceId))
+			}
+		}
+	}
+	for sliceID, slice := range site.Slice {
+		sliceNode := siteChild(KindSlice, sliceID)
+		g.addChild(siteNode, sliceNode)
+		for _, dgLink := range slice.DeviceGroup {
+			if dgLink.DeviceGroup != nil {
+				g.addEdge(sliceNode, siteChild(KindDeviceGroup, *dgLink.DeviceGroup))
+			}
+		}
+		for _, filter := range slice.Filter {
+			if filter.Application != nil {
+				g.addEdge(sliceNode, NodeID{Kind: KindApplication, Enterprise: entID, ID: *filter.Application})
+			}
+		}
+		if slice.Upf != nil {
+			g.addEdge(sliceNode, siteChild(KindUpf, *slice.Upf))
+			g.upfs[sliceNode] = *slice.Upf
+			// The slice can fail over to the standbys of its UPF
+			for _, upfID := range g.standbyUpfs[*slice.Upf] {
+				if _, okay := site.Upf[upfID]; okay {
+					g.addEdge(sliceNode, siteChild(KindUpf, upfID))
+				}
+			}
+		}
+		// The slice lists every small cell in its site
+		for scID := range site.SmallCell {
+			g.addEdge(sliceNode, siteChild(KindSmallCell, scID))
+		}
+	}
+}
+
+// SliceTemplates binds slices to the templates that they inherit their unset fields from.
+// The model has no reference from a slice to a template, so the binding is configured.
+type SliceTemplates struct {
+	// Bindings maps a slice ID to the ID of its template
+	Bindings map[string]string
+
+	// Default is the template of slices that have no binding, or "" if they have none
+	Default string
+}
+
+// Template returns the ID of the template of slice sliceID in enterprise, or "" if it has
+// none. A binding to a template that the enterprise does not have is ignored, as slice IDs
+// are bound in every enterprise. A nil SliceTemplates binds no slices.
+func (t *SliceTemplates) Template(enterprise *models.OnfEnterprise_Enterprises_Enterprise, sliceID string) string {
+	if t == nil {
+		return ""
+	}
+	tpID, okay := t.Bindings[sliceID]
+	if !okay {
+		tpID = t.Default
+	}
+	if (tpID == "") || (enterprise.Template[tpID] == nil) {
+		return ""
+	}
+	return tpID
+}
+
+// Has returns true if node is in the model
+func (g *Graph) Has(node NodeID) bool {
+	return g.nodes[node]
+}
+
+// sortNodes sorts nodes by kind, scope and id
+func sortNodes(nodes []NodeID) {
+	sort.Slice(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Enterprise != b.Enterprise {
+			return a.Enterprise < b.Enterprise
+		}
+		if a.Site != b.Site {
+			return a.Site < b.Site
+		}
+		return a.ID < b.ID
+	})
+}
+
+// Uses returns the nodes that node refers to directly, including those that are missing
+// from the model
+func (g *Graph) Uses(node NodeID) []NodeID {
+	nodes := []NodeID{}
+	for used := range g.uses[node] {
+		nodes = append(nodes, used)
+	}
+	sortNodes(nodes)
+	return nodes
+}
+
+// Referrers returns the nodes that refer to node directly, not counting the objects it
+// contains
+func (g *Graph) Referrers(node NodeID) []NodeID {
+	nodes := []NodeID{}
+	for referrer := range g.usedBy[node] {
+		if !g.contains[node][referrer] {
+			nodes = append(nodes, referrer)
+		}
+	}
+	sortNodes(nodes)
+	return nodes
+}
+
+// Dangling returns the references to nodes that are not in the model
+func (g *Graph) Dangling() []Reference {
+	refs := []Reference{}
+	for from, uses := range g.uses {
+		for to := range uses {
+			if !g.nodes[to] {
+				refs = append(refs, Reference{From: from, To: to})
+			}
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].From != refs[j].From {
+			return refs[i].From.String() < refs[j].From.String()
+		}
+		return refs[i].To.String() < refs[j].To.String()
+	})
+	return refs
+}
+
+// Dependents returns every node that depends on node, directly or transitively
+func (g *Graph) Dependents(node NodeID) []NodeID {
+	seen := map[NodeID]bool{node: true}
+	queue := []NodeID{node}
+	nodes := []NodeID{}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for dependent := range g.usedBy[next] {
+			if seen[dependent] {
+				continue
+			}
+			seen[dependent] = true
+			nodes = append(nodes, dependent)
+			queue = append(queue, dependent)
+		}
+	}
+	sortNodes(nodes)
+	return nodes
+}
+
+// Impact returns the nodes and southbound objects that are affected by changing or deleting
+// node. The node does not need to be in the model, so that the impact of a reference to a
+// missing object can be found.
+func (g *Graph) Impact(node NodeID) *Impact {
+	impact := &Impact{
+		Node:       node,
+		Referrers:  g.Referrers(node),
+		Dependents: g.Dependents(node),
+		Southbound: []Southbound{},
+	}
+
+	affected := append([]NodeID{node}, impact.Dependents...)
+	for _, n := range affected {
+		if ((n.Kind != KindDeviceGroup) && (n.Kind != KindSlice)) || !g.nodes[n] {
+			continue
+		}
+		for _, csID := range g.csByEnterprise[n.Enterprise] {
+			impact.Southbound = append(impact.Southbound, Southbound{
+				Kind:                n.Kind,
+				ID:                  n.ID,
+				Enterprise:          n.Enterprise,
+				Site:                n.Site,
+				ConnectivityService: csID,
+				Upf:                 g.upfs[n],
+			})
+		}
+	}
+
+	sort.Slice(impact.Southbound, func(i, j int) bool {
+		a, b := impact.Southbound[i], impact.Southbound[j]
+		if a.ConnectivityService != b.ConnectivityService {
+			return a.ConnectivityService < b.ConnectivityService
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Enterprise != b.Enterprise {
+			return a.Enterprise < b.Enterprise
+		}
+		if a.Site != b.Site {
+			return a.Site < b.Site
+		}
+		return a.ID < b.ID
+	})
+
+	return impact
+}
@@ -0,0 +1,229 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package refgraph
+
+import (
+	models "github.com/onosproject/aether-models/models/aether-2.0.x/api"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// buildDevice builds an enterprise with one site, holding two device-groups that share an
+// ip-domain and a slice that uses one of them
+func buildDevice() *models.Device {
+	site := &models.OnfEnterprise_Enterprises_Enterprise_Site{
+		SiteId: ygot.String("site1"),
+		IpDomain: map[string]*models.OnfEnterprise_Enterprises_Enterprise_Site_IpDomain{
+			"ipd1": {IpDomainId: ygot.String("ipd1")},
+		},
+		SimCard: map[string]*models.OnfEnterprise_Enterprises_Enterprise_Site_SimCard{
+			"sim1": {SimId: ygot.String("sim1")},
+		},
+		Device: map[string]*models.OnfEnterprise_Enterprises_Enterprise_Site_Device{
+			"dev1": {DeviceId: ygot.String("dev1"), SimCard: ygot.String("sim1")},
+		},
+		Upf: map[string]*models.OnfEnterprise_Enterprises_Enterprise_Site_Upf{
+			"upf1": {UpfId: ygot.String("upf1")},
+		},
+		DeviceGroup: map[string]*models.OnfEnterprise_Enterprises_Enterprise_Site_DeviceGroup{
+			"dg1": {
+				DeviceGroupId: ygot.String("dg1"),
+				IpDomain:      ygot.String("ipd1"),
+				TrafficClass:  ygot.String("tc1"),
+				Device: map[string]*models.OnfEnterprise_Enterprises_Enterprise_Site_DeviceGroup_Device{
+					"dev1": {DeviceId: ygot.String("dev1")},
+				},
+			},
+			"dg2": {
+				DeviceGroupId: ygot.String("dg2"),
+				IpDomain:      ygot.String("ipd1"),
+			},
+		},
+		Slice: map[string]*models.OnfEnterprise_Enterprises_Enterprise_Site_Slice{
+			"slice1": {
+				SliceId: ygot.String("slice1"),
+				Upf:     ygot.String("upf1"),
+				DeviceGroup: map[string]*models.OnfEnterprise_Enterprises_Enterprise_Site_Slice_DeviceGroup{
+					"dg1": {DeviceGroup: ygot.String("dg1")},
+				},
+				Filter: map[string]*models.OnfEnterprise_Enterprises_Enterprise_Site_Slice_Filter{
+					"app1": {Application: ygot.String("app1")},
+				},
+			},
+		},
+	}
+
+	enterprise := &models.OnfEnterprise_Enterprises_Enterprise{
+		EnterpriseId: ygot.String("ent1"),
+		ConnectivityService: map[string]*models.OnfEnterprise_Enterprises_Enterprise_ConnectivityService{
+			"cs1": {ConnectivityService: ygot.String("cs1")},
+		},
+		TrafficClass: map[string]*models.OnfEnterprise_Enterprises_Enterprise_TrafficClass{
+			"tc1": {TrafficClassId: ygot.String("tc1")},
+			"tc2": {TrafficClassId: ygot.String("tc2")},
+		},
+		Application: map[string]*models.OnfEnterprise_Enterprises_Enterprise_Application{
+			"app1": {
+				ApplicationId: ygot.String("app1"),
+				Endpoint: map[string]*models.OnfEnterprise_Enterprises_Enterprise_Application_Endpoint{
+					"ep1": {EndpointId: ygot.String("ep1"), TrafficClass: ygot.String("tc2")},
+				},
+			},
+		},
+		Template: map[string]*models.OnfEnterprise_Enterprises_Enterprise_Template{
+			"tp1": {TemplateId: ygot.String("tp1")},
+		},
+		Site: map[string]*models.OnfEnterprise_Enterprises_Enterprise_Site{"site1": site},
+	}
+
+	return &models.Device{
+		Enterprises: &models.OnfEnterprise_Enterprises{
+			Enterprise: map[string]*models.OnfEnterprise_Enterprises_Enterprise{"ent1": enterprise},
+		},
+		ConnectivityServices: &models.OnfConnectivityService_ConnectivityServices{
+			ConnectivityService: map[string]*models.OnfConnectivityService_ConnectivityServices_ConnectivityService{
+				"cs1": {ConnectivityServiceId: ygot.String("cs1")},
+			},
+		},
+	}
+}
+
+func siteNode(kind NodeKind, id string) NodeID {
+	return NodeID{Kind: kind, Enterprise: "ent1", Site: "site1", ID: id}
+}
+
+func southboundIDs(impact *Impact) []string {
+	ids := []string{}
+	for _, obj := range impact.Southbound {
+		ids = append(ids, string(obj.Kind)+"/"+obj.ID)
+	}
+	return ids
+}
+
+func TestNewNodeID(t *testing.T) {
+	node, err := NewNodeID(KindTrafficClass, "ent1", "ignored", "tc1")
+	assert.Nil(t, err)
+	assert.Equal(t, NodeID{Kind: KindTrafficClass, Enterprise: "ent1", ID: "tc1"}, node)
+	assert.Equal(t, "traffic-class ent1/tc1", node.String())
+
+	node, err = NewNodeID(KindDeviceGroup, "ent1", "site1", "dg1")
+	assert.Nil(t, err)
+	assert.Equal(t, siteNode(KindDeviceGroup, "dg1"), node)
+
+	_, err = NewNodeID(KindDeviceGroup, "ent1", "", "dg1")
+	assert.EqualError(t, err, "device-group dg1 requires an enterprise and a site")
+
+	_, err = NewNodeID("imsi-definition", "ent1", "site1", "x")
+	assert.EqualError(t, err, "Unknown kind imsi-definition")
+}
+
+func TestUsesAndReferrers(t *testing.T) {
+	g := Build(buildDevice())
+
+	assert.True(t, g.Has(siteNode(KindDeviceGroup, "dg1")))
+	assert.False(t, g.Has(siteNode(KindDeviceGroup, "missing")))
+
+	assert.Equal(t, []NodeID{
+		siteNode(KindDevice, "dev1"),
+		siteNode(KindIPDomain, "ipd1"),
+		{Kind: KindSite, Enterprise: "ent1", ID: "site1"},
+		{Kind: KindTrafficClass, Enterprise: "ent1", ID: "tc1"},
+	}, g.Uses(siteNode(KindDeviceGroup, "dg1")))
+
+	// The objects a site contains are not referrers of it
+	assert.Empty(t, g.Referrers(NodeID{Kind: KindSite, Enterprise: "ent1", ID: "site1"}))
+
+	assert.Equal(t, []NodeID{siteNode(KindDeviceGroup, "dg1"), siteNode(KindDeviceGroup, "dg2")},
+		g.Referrers(siteNode(KindIPDomain, "ipd1")))
+}
+
+func TestImpact(t *testing.T) {
+	g := Build(buildDevice())
+
+	tests := []struct {
+		name       string
+		node       NodeID
+		southbound []string
+	}{
+		{"ip-domain", siteNode(KindIPDomain, "ipd1"), []string{"device-group/dg1", "device-group/dg2", "slice/slice1"}},
+		{"sim-card", siteNode(KindSimCard, "sim1"), []string{"device-group/dg1", "slice/slice1"}},
+		{"unused device-group", siteNode(KindDeviceGroup, "dg2"), []string{"device-group/dg2"}},
+		{"upf", siteNode(KindUpf, "upf1"), []string{"slice/slice1"}},
+		{"device-group traffic-class", NodeID{Kind: KindTrafficClass, Enterprise: "ent1", ID: "tc1"}, []string{"device-group/dg1", "slice/slice1"}},
+		{"application traffic-class", NodeID{Kind: KindTrafficClass, Enterprise: "ent1", ID: "tc2"}, []string{"slice/slice1"}},
+		{"template", NodeID{Kind: KindTemplate, Enterprise: "ent1", ID: "tp1"}, []string{}},
+		{"connectivity-service", NodeID{Kind: KindConnectivityService, ID: "cs1"}, []string{"device-group/dg1", "device-group/dg2", "slice/slice1"}},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.southbound, southboundIDs(g.Impact(test.node)), test.name)
+	}
+
+	impact := g.Impact(siteNode(KindUpf, "upf1"))
+	assert.Equal(t, []NodeID{siteNode(KindSlice, "slice1")}, impact.Referrers)
+	assert.Equal(t, []Southbound{{Kind: KindSlice, ID: "slice1", Enterprise: "ent1", Site: "site1", ConnectivityService: "cs1", Upf: "upf1"}}, impact.Southbound)
+
+	// A missing object that nothing refers to has no impact
+	impact = g.Impact(NodeID{Kind: KindTrafficClass, Enterprise: "ent1", ID: "missing"})
+	assert.Empty(t, impact.Referrers)
+	assert.Empty(t, impact.Southbound)
+}
+
+func TestDangling(t *testing.T) {
+	device := buildDevice()
+	g := Build(device)
+	assert.Empty(t, g.Dangling())
+
+	site := device.Enterprises.Enterprise["ent1"].Site["site1"]
+	site.Slice["slice1"].Upf = ygot.String("missing-upf")
+	delete(site.SimCard, "sim1")
+
+	g = Build(device)
+	assert.Equal(t, []Reference{
+		{From: siteNode(KindDevice, "dev1"), To: siteNode(KindSimCard, "sim1")},
+		{From: siteNode(KindSlice, "slice1"), To: siteNode(KindUpf, "missing-upf")},
+	}, g.Dangling())
+}
+
+func TestStandbyUpfs(t *testing.T) {
+	device := buildDevice()
+	site := device.Enterprises.Enterprise["ent1"].Site["site1"]
+	site.Upf["upf3"] = &models.OnfEnterprise_Enterprises_Enterprise_Site_Upf{UpfId: ygot.String("upf3")}
+	site.Upf["upf2"] = &models.OnfEnterprise_Enterprises_Enterprise_Site_Upf{UpfId: ygot.String("upf2")}
+
+	// A UPF that no slice uses is not a standby unless it is configured as one
+	impact := Build(device).Impact(siteNode(KindUpf, "upf2"))
+	assert.Empty(t, southboundIDs(impact))
+
+	// A change to a standby pushes the slices that can fail over to it
+	standbys := map[string][]string{"upf1": {"upf2", "missing-upf"}}
+	g := Build(device, WithStandbyUpfs(standbys))
+	assert.Equal(t, []string{"slice/slice1"}, southboundIDs(g.Impact(siteNode(KindUpf, "upf2"))))
+	assert.Empty(t, southboundIDs(g.Impact(siteNode(KindUpf, "upf3"))))
+	assert.Empty(t, g.Dangling())
+}
+
+func TestSliceTemplate(t *testing.T) {
+	device := buildDevice()
+	enterprise := device.Enterprises.Enterprise["ent1"]
+	enterprise.Template["tp2"] = &models.OnfEnterprise_Enterprises_Enterprise_Template{TemplateId: ygot.String("tp2")}
+
+	// Without a binding, a slice has no template, even one with its own ID
+	var none *SliceTemplates
+	assert.Equal(t, "", none.Template(enterprise, "slice1"))
+	enterprise.Template["slice1"] = &models.OnfEnterprise_Enterprises_Enterprise_Template{TemplateId: ygot.String("slice1")}
+	assert.Equal(t, "", (&SliceTemplates{}).Template(enterprise, "slice1"))
+
+	// A binding wins over the default, and a binding to a missing template is ignored
+	templates := &SliceTemplates{Bindings: map[string]string{"slice1": "tp2", "slice2": "missing"}, Default: "slice1"}
+	assert.Equal(t, "tp2", templates.Template(enterprise, "slice1"))
+	assert.Equal(t, "", templates.Template(enterprise, "slice2"))
+	assert.Equal(t, "slice1", templates.Template(enterprise, "slice3"))
+
+	impact := Build(device, WithSliceTemplates(templates)).Impact(NodeID{Kind: KindTemplate, Enterprise: "ent1", ID: "tp2"})
+	assert.Equal(t, []string{"slice/slice1"}, southboundIDs(impact))
+	assert.Empty(t, southboundIDs(Build(device).Impact(NodeID{Kind: KindTemplate, Enterprise: "ent1", ID: "tp2"})))
+}
@@ -0,0 +1,106 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Address implements the handling of IPv4 and IPv6 addresses and prefixes in applications,
+// filter rules and ip-domains.
+
+package synchronizer
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ipFamily is the address family of an address or prefix
+type ipFamily string
+
+const (
+	familyIPv4 ipFamily = "IPv4"
+	familyIPv6 ipFamily = "IPv6"
+
+	// MinIPv6MTU is the smallest MTU that IPv6 allows
+	MinIPv6MTU = 1280
+)
+
+// parseIP parses an IPv4 or IPv6 address. IPv4-mapped IPv6 addresses are rejected, as they
+// mix the two families.
+func parseIP(s string) (net.IP, ipFamily, error) {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, "", fmt.Errorf("%s is not an IP address", s)
+	}
+	if ip.To4() == nil {
+		return ip, familyIPv6, nil
+	}
+	if strings.Contains(s, ":") {
+		return nil, "", fmt.Errorf("%s mixes IPv4 and IPv6", s)
+	}
+	return ip, familyIPv4, nil
+}
+
+// parsePrefix parses an IPv4 or IPv6 prefix in CIDR notation
+func parsePrefix(s string) (*net.IPNet, ipFamily, error) {
+	slash := strings.Index(s, "/")
+	if slash < 0 {
+		return nil, "", fmt.Errorf("%s is not a prefix", s)
+	}
+	_, family, err := parseIP(s[:slash])
+	if err != nil {
+		return nil, "", err
+	}
+	_, ipNet, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s is not a valid prefix", s)
+	}
+	return ipNet, family, nil
+}
+
+// hostPrefix returns an address or prefix as a prefix. An address becomes a host prefix:
+// /32 for IPv4, or /128 for IPv6.
+func hostPrefix(s string) (string, ipFamily, error) {
+	if strings.Contains(s, "/") {
+		_, family, err := parsePrefix(s)
+		if err != nil {
+			return "", "", err
+		}
+		return s, family, nil
+	}
+
+	_, family, err := parseIP(s)
+	if err != nil {
+		return "", "", err
+	}
+	if family == familyIPv6 {
+		return s + "/128", family, nil
+	}
+	return s + "/32", family, nil
+}
+
+// prefixFamily returns the address family of a prefix that has already been parsed
+func prefixFamily(prefix string) ipFamily {
+	if strings.Contains(prefix, ":") {
+		return familyIPv6
+	}
+	return familyIPv4
+}
+
+// sliceFamilies returns the address families of the UE pools of a slice's device-groups.
+// A slice with no pools that can be parsed is treated as IPv4, as it always has been.
+func (s *Synchronizer) sliceFamilies(scope *AetherScope, dgList []*DeviceGroup) map[ipFamily]bool {
+	families := map[ipFamily]bool{}
+	for _, dg := range dgList {
+		ipd, err := s.GetIPDomain(scope, dg.IpDomain)
+		if (err != nil) || (ipd.Subnet == nil) {
+			continue
+		}
+		if _, family, err := parsePrefix(*ipd.Subnet); err == nil {
+			families[family] = true
+		}
+	}
+	if len(families) == 0 {
+		families[familyIPv4] = true
+	}
+	return families
+}
@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHostPrefix(t *testing.T) {
+	tests := []struct {
+		address string
+		prefix  string
+		family  ipFamily
+		err     string
+	}{
+		{"1.2.3.4", "1.2.3.4/32", familyIPv4, ""},
+		{"1.2.3.0/24", "1.2.3.0/24", familyIPv4, ""},
+		{"2001:db8::1", "2001:db8::1/128", familyIPv6, ""},
+		{"2001:db8::/32", "2001:db8::/32", familyIPv6, ""},
+		{"::ffff:1.2.3.4", "", "", "::ffff:1.2.3.4 mixes IPv4 and IPv6"},
+		{"2001:db8::/129", "", "", "2001:db8::/129 is not a valid prefix"},
+		{"app.example.com", "", "", "app.example.com is not an IP address"},
+	}
+
+	for _, test := range tests {
+		prefix, family, err := hostPrefix(test.address)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err)
+			continue
+		}
+		assert.Nil(t, err)
+		assert.Equal(t, test.prefix, prefix)
+		assert.Equal(t, test.family, family)
+	}
+}
+
+func TestRenderSliceIPv6(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher))
+
+	device := BuildSampleDevice()
+	scope, err := BuildScope(device, "sample-ent", "sample-site", "sample-cs")
+	assert.Nil(t, err)
+	slice := scope.Site.Slice["sample-slice"]
+	slice.Filter = map[string]*SliceFilter{"sample-app": slice.Filter["sample-app"]}
+	scope.Site.IpDomain["sample-ipd"].Subnet = aStr("2001:db8:1::/48")
+	scope.Enterprise.Application["sample-app"].Address = aStr("2001:db8:2::10")
+
+	coreSlice, err := s.renderSlice(scope, slice)
+	assert.Nil(t, err)
+	assert.Equal(t, "2001:db8:2::10/128", coreSlice.ApplicationFilteringRules[0].Endpoint)
+	// An IPv6-only slice gets only the IPv6 rules of its default-behavior
+	assert.Equal(t, appFilterRule{Name: "DENY-ALL-V6", Action: "deny", Priority: 250, Endpoint: "::/0"},
+		coreSlice.ApplicationFilteringRules[1])
+	assert.Len(t, coreSlice.ApplicationFilteringRules, 2)
+
+	// A literal address of the other family is not silently rendered
+	scope.Enterprise.Application["sample-app"].Address = aStr("2001:db8:2::10, 1.2.3.4")
+	_, err = s.renderSlice(scope, slice)
+	assert.EqualError(t, err, "Slice sample-slice Application sample-app has invalid address: 1.2.3.4 is IPv4, but the slice has no IPv4 UE pool")
+
+	scope.Enterprise.Application["sample-app"].Address = aStr("::ffff:1.2.3.4")
+	_, err = s.renderSlice(scope, slice)
+	assert.EqualError(t, err, "Slice sample-slice Application sample-app has invalid address: ::ffff:1.2.3.4 mixes IPv4 and IPv6")
+}
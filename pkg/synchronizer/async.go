@@ -6,9 +6,12 @@
 package synchronizer
 
 import (
+	"fmt"
 	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/ygot/ygot"
 	"sync/atomic"
+	"time"
 )
 
 /*
@@ -18,15 +21,22 @@ import (
  * for processing. If an update is pending and a new update is received, then the older update
  * will be discarded in favor of the newer one (there's no reason to keep old updates, as they're
  * fully obsoleted by newer updates)
+ *
+ * Updates are queued by the gNMI server, the diagnostic API and the background loops, so
+ * queueing is serialized by enqueueMutex. Failed pushes are not retried from here, but from
+ * the retry queue, which has its own loop.
  */
 
-// Drain the synchronizer of any queued updates
-func (s *Synchronizer) drain() {
+// Drain the synchronizer of any queued updates. The paths of the drained updates are merged
+// into next, so that it synchronizes everything they would have.
+func (s *Synchronizer) drain(next *ConfigUpdate) {
 L:
 	for {
 		select {
-		case <-s.updateChannel:
+		case update := <-s.updateChannel:
 			log.Infof("Drained a pending synchronization request")
+			next.mergePath(update)
+			update.complete(fmt.Errorf("Update was superseded before it was synchronized"))
 			atomic.AddInt32(&s.busy, -1)
 		default:
 			break L
@@ -34,8 +44,10 @@ L:
 	}
 }
 
-// Queue an update request for future processing
-func (s *Synchronizer) enqueue(config ygot.ValidatedGoStruct, callbackType gnmi.ConfigCallbackType) error {
+// Queue an update request for future processing. If path is not nil, only the objects it
+// affects are synchronized. If done is not nil, it receives the result of the first
+// synchronization of the update, which only fails for the objects that setPath affects.
+func (s *Synchronizer) enqueue(config ygot.ValidatedGoStruct, callbackType gnmi.ConfigCallbackType, path *pb.Path, done chan error, setPath *pb.Path) error {
 	// Make a copy of the gostruct; we don't want it to change out from under us
 	// if the gnmi server is updating it.
 	configCopy, err := ygot.DeepCopy(config)
@@ -48,19 +60,114 @@ func (s *Synchronizer) enqueue(config ygot.ValidatedGoStruct, callbackType gnmi.
 	update := ConfigUpdate{
 		config:       configCopy.(ygot.ValidatedGoStruct),
 		callbackType: callbackType,
+		path:         path,
+		done:         done,
+		setPath:      setPath,
 	}
 
+	s.enqueueMutex.Lock()
+	defer s.enqueueMutex.Unlock()
+
+	// Remember the latest config, so that reconcile can compare the core against it
+	s.setLastConfig(update.config)
+
 	// Increment our busy count
 	atomic.AddInt32(&s.busy, 1)
 
 	// We don't care about any pending synchronizations; throw away any old ones
 	// and queue the latest one.
-	s.drain()
+	s.drain(&update)
 	s.updateChannel <- &update
+	s.updateWake()
 
 	return nil
 }
 
+// requestResync synchronizes the most recent config again, in full. If an update is already
+// pending, it is widened to synchronize everything, rather than being replaced by an older
+// config. Used by the background loops, which must never queue a config that a Set has
+// since replaced.
+func (s *Synchronizer) requestResync() {
+	s.enqueueMutex.Lock()
+	defer s.enqueueMutex.Unlock()
+
+	select {
+	case update := <-s.updateChannel:
+		update.path = nil
+		s.updateChannel <- update
+	default:
+		s.reconcileMutex.Lock()
+		config := s.lastConfig
+		s.reconcileMutex.Unlock()
+		if config == nil {
+			return
+		}
+		atomic.AddInt32(&s.busy, 1)
+		s.updateChannel <- &ConfigUpdate{config: config, callbackType: gnmi.Forced}
+	}
+	s.updateWake()
+}
+
+// supersede abandons an update in favor of the pending one, whose path is widened to cover
+// what the abandoned update would have synchronized
+func (s *Synchronizer) supersede(update *ConfigUpdate) {
+	s.enqueueMutex.Lock()
+	defer s.enqueueMutex.Unlock()
+
+	update.complete(fmt.Errorf("Update was superseded before it was synchronized"))
+	select {
+	case next := <-s.updateChannel:
+		next.mergePath(update)
+		s.updateChannel <- next
+	default:
+	}
+}
+
+// updateWake interrupts a synchronization that is waiting to retry, as a newer update has
+// arrived
+func (s *Synchronizer) updateWake() {
+	select {
+	case s.updateWakeChannel <- struct{}{}:
+	default:
+	}
+}
+
+// updateSleep waits for delay. Returns false if it was interrupted by a newer update.
+func (s *Synchronizer) updateSleep(delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-s.updateWakeChannel:
+		return false
+	}
+}
+
+// mergePath widens the path of update to cover what an older update would have synchronized.
+// A nil path synchronizes everything.
+func (update *ConfigUpdate) mergePath(older *ConfigUpdate) {
+	if (update.path == nil) || (older.path == nil) {
+		update.path = nil
+		return
+	}
+	update.path = gnmi.CommonPathPrefix([]*pb.Path{older.path, update.path})
+}
+
+// complete sends the result of synchronizing an update to whoever is waiting for it
+func (update *ConfigUpdate) complete(err error) {
+	if update.done == nil {
+		return
+	}
+	// The channel is buffered, and the waiter may have timed out; never block
+	select {
+	case update.done <- err:
+	default:
+	}
+	update.done = nil
+}
+
 // Dequeue an update request. This call will block until a request is ready.
 func (s *Synchronizer) dequeue() *ConfigUpdate {
 	update := <-s.updateChannel
@@ -79,7 +186,7 @@ func (s *Synchronizer) newUpdatesPending() bool {
 }
 
 // Returns true if the synchronizer is idle; if there are no requests being
-// worked on and no pending requests.
+// worked on, no pending requests, and no failed pushes waiting to be retried.
 func (s *Synchronizer) isIdle() bool {
-	return atomic.LoadInt32(&s.busy) == 0
+	return (atomic.LoadInt32(&s.busy) == 0) && (s.retryPending() == 0)
 }
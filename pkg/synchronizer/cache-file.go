@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// FileCacheStore implements a CacheStore that persists the cache to a file.
+
+package synchronizer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// DefaultCacheDir is the default directory in which the cache is persisted
+	DefaultCacheDir = "/var/lib/sdcore-adapter/cache"
+
+	// DefaultCacheFileName is the name of the file that holds the cache within the cache directory
+	DefaultCacheFileName = "push-cache.json"
+)
+
+// FileCacheStore is a CacheStore that saves the cache as a JSON document in a directory.
+// Every save writes a temporary file and renames it over the previous one, so that
+// a crash during a save leaves the last good copy in place.
+type FileCacheStore struct {
+	dir string
+}
+
+// NewFileCacheStore creates a FileCacheStore that keeps its file in dir
+func NewFileCacheStore(dir string) *FileCacheStore {
+	return &FileCacheStore{dir: dir}
+}
+
+// FileName returns the full path of the cache file
+func (f *FileCacheStore) FileName() string {
+	return filepath.Join(f.dir, DefaultCacheFileName)
+}
+
+// Load reads the cache file. A missing file yields an empty cache.
+func (f *FileCacheStore) Load() (map[string][]byte, error) {
+	data, err := ioutil.ReadFile(f.FileName())
+	if os.IsNotExist(err) {
+		return map[string][]byte{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	raw := map[string]json.RawMessage{}
+	err = json.Unmarshal(data, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := map[string][]byte{}
+	for k, v := range raw {
+		entries[k] = []byte(v)
+	}
+
+	return entries, nil
+}
+
+// Save atomically replaces the cache file with the given entries
+func (f *FileCacheStore) Save(entries map[string][]byte) error {
+	raw := map[string]json.RawMessage{}
+	for k, v := range entries {
+		raw[k] = json.RawMessage(v)
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(f.dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(f.FileName(), data)
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory as fileName,
+// syncs it, and then renames it to fileName.
+func writeFileAtomic(fileName string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(fileName), "."+filepath.Base(fileName)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpName)
+		return err
+	}
+
+	return os.Rename(tmpName, fileName)
+}
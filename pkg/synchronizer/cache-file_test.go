@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestFileCacheStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "synchronizer-cache")
+	assert.Nil(t, err)
+	defer func() {
+		assert.Nil(t, os.RemoveAll(dir))
+	}()
+
+	store := NewFileCacheStore(dir)
+
+	// A missing file is an empty cache
+	entries, err := store.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(entries))
+
+	err = store.Save(map[string][]byte{"slice-one": []byte(`{"a":1}`), "slice-two": []byte(`[1,2]`)})
+	assert.Nil(t, err)
+
+	entries, err = store.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]byte{"slice-one": []byte(`{"a":1}`), "slice-two": []byte(`[1,2]`)}, entries)
+
+	// A corrupt file is an error
+	err = ioutil.WriteFile(store.FileName(), []byte("not json"), 0644)
+	assert.Nil(t, err)
+	_, err = store.Load()
+	assert.NotNil(t, err)
+}
+
+func TestCachePersistsAcrossRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "synchronizer-cache")
+	assert.Nil(t, err)
+	defer func() {
+		assert.Nil(t, os.RemoveAll(dir))
+	}()
+
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	pushes := []string{}
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).DoAndReturn(func(endpoint string, data []byte) error {
+		pushes = append(pushes, endpoint)
+		return nil
+	}).AnyTimes()
+
+	s := NewSynchronizer(WithPusher(mockPusher), WithCacheStore(NewFileCacheStore(dir)))
+	assert.Nil(t, s.CacheLoad())
+
+	device := BuildSampleDevice()
+	pushErrors, err := s.SynchronizeDevice(device)
+	assert.Equal(t, 0, pushErrors)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(pushes))
+
+	// A new synchronizer using the same directory should not push anything
+	s = NewSynchronizer(WithPusher(mockPusher), WithCacheStore(NewFileCacheStore(dir)))
+	assert.Nil(t, s.CacheLoad())
+	pushErrors, err = s.SynchronizeDevice(device)
+	assert.Equal(t, 0, pushErrors)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(pushes))
+
+	// Invalidating the cache persists as well
+	s.CacheInvalidate()
+	s = NewSynchronizer(WithPusher(mockPusher), WithCacheStore(NewFileCacheStore(dir)))
+	assert.Nil(t, s.CacheLoad())
+	pushErrors, err = s.SynchronizeDevice(device)
+	assert.Equal(t, 0, pushErrors)
+	assert.Nil(t, err)
+	assert.Equal(t, 6, len(pushes))
+}
+
+// countingCacheStore is a CacheStore that counts its saves
+type countingCacheStore struct {
+	saves   int
+	entries map[string][]byte
+}
+
+func (c *countingCacheStore) Load() (map[string][]byte, error) {
+	return c.entries, nil
+}
+
+func (c *countingCacheStore) Save(entries map[string][]byte) error {
+	c.saves++
+	c.entries = entries
+	return nil
+}
+
+func TestCacheSavedOncePerPass(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	store := &countingCacheStore{}
+	s := NewSynchronizer(WithPusher(mockPusher), WithCacheStore(store))
+
+	// Three objects are pushed, and the cache is written once
+	device := BuildSampleDevice()
+	_, err := s.SynchronizeDevice(device)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, store.saves)
+	assert.Equal(t, 3, len(store.entries))
+
+	// Nothing changed, so nothing is written
+	_, err = s.SynchronizeDevice(device)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, store.saves)
+}
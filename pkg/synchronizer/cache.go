@@ -7,8 +7,9 @@
 package synchronizer
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
-	"reflect"
 )
 
 const (
@@ -22,33 +23,162 @@ const (
 	CacheModelDeviceGroup = "devicegroup"
 )
 
+// CacheStore is a backend that persists the contents of the cache, so that the
+// cache survives a restart of the adapter.
+type CacheStore interface {
+	// Load returns all entries that were previously saved
+	Load() (map[string][]byte, error)
+
+	// Save replaces the saved entries with the given entries
+	Save(entries map[string][]byte) error
+}
+
+// cacheKey returns the key used to store (modelName, modelID) in the cache
+func cacheKey(modelName string, modelID string) string {
+	return fmt.Sprintf("%s-%s", modelName, modelID)
+}
+
 // CacheCheck returns true if (modelName, modelId) exists in the cache and the contents have not
 // changed.
 func (s *Synchronizer) CacheCheck(modelName string, modelID string, contents interface{}) bool {
-	key := fmt.Sprintf("%s-%s", modelName, modelID)
-	entry, okay := s.cache[key]
+	data, err := json.Marshal(contents)
+	if err != nil {
+		return false
+	}
+
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+
+	entry, okay := s.cache[cacheKey(modelName, modelID)]
 	if !okay {
 		return false
 	}
 
-	return reflect.DeepEqual(entry, contents) // (entry == contents)
+	return bytes.Equal(entry, data)
 }
 
 // CacheUpdate updates the contents of (modelName, modelID) in the cache with new contents
 func (s *Synchronizer) CacheUpdate(modelName string, modelID string, contents interface{}) {
-	key := fmt.Sprintf("%s-%s", modelName, modelID)
-	s.cache[key] = contents
+	if s.dryRun {
+		// Nothing was pushed, so the cache must continue to reflect what the core has
+		return
+	}
+
+	data, err := json.Marshal(contents)
+	if err != nil {
+		log.Warnf("Failed to marshal cache entry %s: %v", cacheKey(modelName, modelID), err)
+		return
+	}
+
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+
+	s.cache[cacheKey(modelName, modelID)] = data
+	s.cacheDirty = true
+}
+
+// cacheUpdateJSON updates the contents of (modelName, modelID) in the cache with a JSON
+// document that was pushed, for when the contents themselves are no longer available.
+func (s *Synchronizer) cacheUpdateJSON(modelName string, modelID string, data []byte) {
+	if s.dryRun {
+		return
+	}
+
+	// Entries are compared byte for byte, so store them the way json.Marshal would
+	compact := &bytes.Buffer{}
+	err := json.Compact(compact, data)
+	if err != nil {
+		log.Warnf("Failed to compact cache entry %s: %v", cacheKey(modelName, modelID), err)
+		return
+	}
+
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+
+	s.cache[cacheKey(modelName, modelID)] = compact.Bytes()
+	s.cacheDirty = true
 }
 
 // CacheInvalidate removes all entries in the cache
 func (s *Synchronizer) CacheInvalidate() {
-	s.cache = map[string]interface{}{}
+	s.cacheMutex.Lock()
+	s.cache = map[string][]byte{}
+	s.cacheDirty = true
+	s.cacheMutex.Unlock()
+
+	s.cacheFlush()
 }
 
 // CacheDelete removes a single entry from the cache
 func (s *Synchronizer) CacheDelete(modelName string, modelID string) {
-	key := fmt.Sprintf("%s-%s", modelName, modelID)
+	if s.dryRun {
+		return
+	}
+
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
 
 	// delete does not crash if the key does not exist
-	delete(s.cache, key)
+	delete(s.cache, cacheKey(modelName, modelID))
+	s.cacheDirty = true
+}
+
+// CacheLoad populates the cache from the cache store, if one is configured.
+func (s *Synchronizer) CacheLoad() error {
+	if s.cacheStore == nil {
+		return nil
+	}
+
+	entries, err := s.cacheStore.Load()
+	if err != nil {
+		return err
+	}
+
+	s.cacheMutex.Lock()
+	defer s.cacheMutex.Unlock()
+
+	s.cache = entries
+	if s.cache == nil {
+		s.cache = map[string][]byte{}
+	}
+
+	log.Infof("Loaded %d entries into the cache", len(s.cache))
+
+	return nil
+}
+
+// cacheFlush writes the cache to the cache store, if one is configured and the cache has
+// changed since it was last written. Changes are only recorded in memory as they are made,
+// and written once at the end of each synchronization pass, delete or retry, rather than
+// once per object.
+func (s *Synchronizer) cacheFlush() {
+	if s.cacheStore == nil {
+		return
+	}
+
+	// Saves are serialized, so that an older copy never overwrites a newer one
+	s.cacheSaveMutex.Lock()
+	defer s.cacheSaveMutex.Unlock()
+
+	s.cacheMutex.Lock()
+	if !s.cacheDirty {
+		s.cacheMutex.Unlock()
+		return
+	}
+	entries := make(map[string][]byte, len(s.cache))
+	for k, v := range s.cache {
+		entries[k] = v
+	}
+	s.cacheDirty = false
+	s.cacheMutex.Unlock()
+
+	// A failure to save is not fatal; the worst that happens is that we push
+	// some objects again after a restart.
+	err := s.cacheStore.Save(entries)
+	if err != nil {
+		log.Warnf("Failed to save cache: %v", err)
+		s.cacheMutex.Lock()
+		s.cacheDirty = true
+		s.cacheMutex.Unlock()
+	}
 }
@@ -6,9 +6,12 @@
 package synchronizer
 
 import (
+	"sync"
 	"time"
 
 	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	"github.com/onosproject/sdcore-adapter/pkg/refgraph"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/ygot/ygot"
 )
 
@@ -21,6 +24,38 @@ const (
 
 	// DefaultPartialUpdateEnable is the default partial update setting
 	DefaultPartialUpdateEnable = true
+
+	// DefaultRetryInterval is the default delay before the first retry of a failed push
+	DefaultRetryInterval = time.Second * 5
+
+	// DefaultRetryMaxInterval is the default cap on the delay between retries
+	DefaultRetryMaxInterval = time.Minute * 5
+
+	// RetryJitter is the fraction of a retry delay that is randomized
+	RetryJitter = 0.2
+
+	// DefaultWorkers is the default number of sites that are synchronized in parallel
+	DefaultWorkers = 4
+
+	// DefaultReconcileSafeMode is the default reconcile safe mode setting
+	DefaultReconcileSafeMode = true
+
+	// DefaultStrictTimeout is the default time a strict mode Set waits for its pushes. The
+	// gNMI server holds its lock for as long as the Set waits.
+	DefaultStrictTimeout = time.Second * 10
+
+	// DefaultTargetedSyncEnable is the default targeted synchronization setting
+	DefaultTargetedSyncEnable = true
+
+	// DefaultUpfProbeInterval is the default interval between probes of the UPF config endpoints
+	DefaultUpfProbeInterval = time.Second * 10
+
+	// DefaultFQDNRefreshInterval is the default interval between resolving the host names
+	// of applications again
+	DefaultFQDNRefreshInterval = time.Minute * 5
+
+	// DefaultResolveTimeout is the default timeout for resolving a host name
+	DefaultResolveTimeout = time.Second * 5
 )
 
 // Synchronizer is a Version 3 synchronizer.
@@ -30,25 +65,103 @@ type Synchronizer struct {
 	postTimeout         time.Duration
 	pusher              PusherInterface
 	updateChannel       chan *ConfigUpdate
+	updateWakeChannel   chan struct{}
+	enqueueMutex        sync.Mutex
+	passMutex           sync.Mutex // held by each synchronization pass, and by a drift repush
 	retryInterval       time.Duration
+	retryMaxInterval    time.Duration
 	partialUpdateEnable bool
+	outputSink          *OutputSink
+	dryRun              bool
+	workers             int
+	strictMode          bool
+	strictTimeout       time.Duration
+	targetedSyncEnable  bool
+	filterPolicies      map[string]*FilterPolicy
+	rulePriorityMode    string
+	sliceTemplates      *refgraph.SliceTemplates
 
 	// Busy indicator, primarily used for unit testing. The channel length in and of itself
 	// is not sufficient, as it does not include the potential update that is currently syncing.
 	// >0 if the synchronizer has operations pending and/or in-progress
 	busy int32
 
-	// used for ease of mocking
+	// used for ease of mocking; if set, replaces the synchronization of a device
 	synchronizeDeviceFunc func(config ygot.ValidatedGoStruct) (int, error)
 
-	// cache of previously synchronized updates
-	cache map[string]interface{}
+	// cache of previously synchronized updates, stored as JSON
+	cache          map[string][]byte
+	cacheDirty     bool
+	cacheMutex     sync.Mutex
+	cacheSaveMutex sync.Mutex
+	cacheStore     CacheStore
+
+	// plan of pushes computed in dry-run mode
+	plan        *Plan
+	pendingPlan *Plan
+	planMutex   sync.Mutex
+
+	// synchronization status of each southbound object
+	status      map[string]*ObjectStatus
+	statusMutex sync.Mutex
+
+	// failed pushes waiting to be retried, and a channel to wake the retry loop
+	retryQueue  map[string]*retryItem
+	retryMutex  sync.Mutex
+	wakeChannel chan struct{}
+
+	// garbage collection of objects on the core that are no longer in the model
+	fetcher           FetcherInterface
+	reconcileInterval time.Duration
+	reconcileSafeMode bool
+	reconcileReport   *ReconcileReport
+	lastConfig        ygot.ValidatedGoStruct
+	reconcileMutex    sync.Mutex
+
+	// detection of changes made directly on the core
+	driftInterval time.Duration
+	driftRepush   bool
+	driftReport   *DriftReport
+	driftMutex    sync.Mutex
+
+	// failover of slices to standby UPFs, keyed by UPF config endpoint and slice status key
+	upfFailoverWindow time.Duration
+	upfProbeInterval  time.Duration
+	upfStandbys       map[string][]string
+	upfDownSince      map[string]time.Time
+	upfActive         map[string]string
+	upfMutex          sync.Mutex
+
+	// serializes the pushes of each slice to each UPF, keyed by slice-upf cache ID
+	upfPushLocks     map[string]*sync.Mutex
+	upfPushLockMutex sync.Mutex
+
+	// resolution of the host names in application addresses, keyed by host name
+	resolver            ResolverInterface
+	fqdnRefreshInterval time.Duration
+	fqdnAnswers         map[string][]string
+	fqdnMisses          map[string]map[string]int // answers each address has been missing from
+	fqdnErrors          map[string]error          // host names that have no answer, and failed
+	fqdnPending         map[string]bool           // host names waiting to be resolved
+	fqdnWakeChannel     chan struct{}
+	fqdnMutex           sync.Mutex
 }
 
 // ConfigUpdate holds the configuration for a particular synchronization request
 type ConfigUpdate struct {
 	config       ygot.ValidatedGoStruct
 	callbackType gnmi.ConfigCallbackType
+
+	// The common prefix of the paths changed by the update, or nil to synchronize everything
+	path *pb.Path
+
+	// In strict mode, receives the result of the first synchronization of the update
+	done chan error
+
+	// In strict mode, the common prefix of the paths changed by the Set, even when
+	// targeted synchronization is disabled. Only failures of the objects it affects
+	// fail the Set.
+	setPath *pb.Path
 }
 
 // SynchronizerOption is for options passed when creating a new synchronizer
@@ -90,7 +90,7 @@ func (s *Synchronizer) GetEnterpriseObjectID(scope *AetherScope, modelName strin
 func (s *Synchronizer) deleteSliceByID(scope *AetherScope, id *string) error {
 	log.Infof("Delete slice %s", *id)
 
-	_, err := s.GetSlice(scope, id)
+	slice, err := s.GetSlice(scope, id)
 	if err != nil {
 		return err
 	}
@@ -104,7 +104,7 @@ func (s *Synchronizer) deleteSliceByID(scope *AetherScope, id *string) error {
 csLoop:
 	for _, cs := range csList {
 		url := fmt.Sprintf("%s/v1/network-slice/%s", *cs.Core_5GEndpoint, *id)
-		err = s.pusher.PushDelete(url)
+		err = s.pushDelete(CacheModelSlice, *id, *cs.ConnectivityServiceId, url)
 		if err != nil {
 			pushError, ok := err.(*PushError)
 			if ok && pushError.StatusCode == 404 {
@@ -118,7 +118,55 @@ csLoop:
 
 	// Remove slice from the cache
 	s.CacheDelete(CacheModelSlice, *id)
-	s.CacheDelete(CacheModelSliceUpf, *id)
+
+	// The UPF is per-site rather than per connectivity service, so it is deleted once
+	err = s.deleteSliceUPF(scope, slice)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// deleteSliceUPF deletes a Slice from the configuration endpoints of its UPF and of any
+// standby UPFs it was pushed to
+func (s *Synchronizer) deleteSliceUPF(scope *AetherScope, slice *Slice) error {
+	for i, upfID := range s.upfCandidateIDs(scope, slice) {
+		err := s.deleteSliceFromUPF(scope, slice, upfID, upfSliceID(*slice.SliceId, upfID, i == 0))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteSliceFromUPF deletes a Slice, cached under id, from one UPF's configuration endpoint
+func (s *Synchronizer) deleteSliceFromUPF(scope *AetherScope, slice *Slice, upfID string, id string) error {
+	aUpf, err := s.GetUpf(scope, &upfID)
+	if err != nil {
+		// Nothing can have been pushed to a UPF that does not exist
+		log.Infof("Slice %s UPF %s not found, not deleting from UPF", *slice.SliceId, upfID)
+		s.CacheDelete(CacheModelSliceUpf, id)
+		return nil
+	}
+
+	if aUpf.ConfigEndpoint == nil {
+		s.CacheDelete(CacheModelSliceUpf, id)
+		return nil
+	}
+
+	// The UPF is not tied to a connectivity service, so no cs is given
+	url := fmt.Sprintf("%s/v1/config/network-slices/%s", *aUpf.ConfigEndpoint, *slice.SliceId)
+	err = s.pushDelete(CacheModelSliceUpf, id, "", url)
+	if err != nil {
+		pushError, ok := err.(*PushError)
+		if !ok || pushError.StatusCode != 404 {
+			return fmt.Errorf("Slice %s failed to push delete to UPF: %s", *slice.SliceId, err)
+		}
+		log.Infof("Tried to delete slice %s from UPF but it does not exist", *slice.SliceId)
+	}
+
+	s.CacheDelete(CacheModelSliceUpf, id)
 
 	return nil
 }
@@ -151,7 +199,7 @@ func (s *Synchronizer) deleteDeviceGroupByID(scope *AetherScope, id *string) err
 csLoop:
 	for _, cs := range csList {
 		url := fmt.Sprintf("%s/v1/device-group/%s", *cs.Core_5GEndpoint, *id)
-		err = s.pusher.PushDelete(url)
+		err = s.pushDelete(CacheModelDeviceGroup, *id, *cs.ConnectivityServiceId, url)
 		if err != nil {
 			pushError, ok := err.(*PushError)
 			if ok && pushError.StatusCode == 404 {
@@ -242,6 +290,7 @@ func (s *Synchronizer) HandleDelete(config ygot.ValidatedGoStruct, path *pb.Path
 	rootDevice := config.(*RootDevice)
 
 	scope := &AetherScope{RootDevice: rootDevice}
+	defer s.cacheFlush()
 
 	if path == nil || len(path.Elem) == 0 {
 		return nil
@@ -93,6 +93,9 @@ func TestHandleDeleteSlice(t *testing.T) {
 	mockPusher.EXPECT().PushDelete("http://5gcore/v1/network-slice/sample-slice").DoAndReturn(func(endpoint string) error {
 		return nil
 	}).AnyTimes()
+	mockPusher.EXPECT().PushDelete("http://upf/v1/config/network-slices/sample-slice").DoAndReturn(func(endpoint string) error {
+		return nil
+	}).Times(1)
 	err := s.HandleDelete(device, path)
 	assert.Nil(t, err)
 }
@@ -108,6 +111,9 @@ func TestHandleDeleteSlicePushError(t *testing.T) {
 	mockPusher.EXPECT().PushDelete("http://5gcore/v1/network-slice/sample-slice").DoAndReturn(func(endpoint string) error {
 		return &PushError{Operation: "DELETE", Endpoint: endpoint, StatusCode: 404, Status: "Not Found"}
 	}).AnyTimes()
+	mockPusher.EXPECT().PushDelete("http://upf/v1/config/network-slices/sample-slice").DoAndReturn(func(endpoint string) error {
+		return &PushError{Operation: "DELETE", Endpoint: endpoint, StatusCode: 404, Status: "Not Found"}
+	}).Times(1)
 	err := s.HandleDelete(device, path)
 	assert.Nil(t, err)
 
@@ -123,6 +129,35 @@ func TestHandleDeleteSlicePushError(t *testing.T) {
 	}).AnyTimes()
 	err = s.HandleDelete(device, path)
 	assert.EqualError(t, err, "Slice sample-slice failed to push delete: Push Error op=DELETE endpoint=http://5gcore/v1/network-slice/sample-slice code=403 status=Forbidden")
+
+	// reset the mockpusher and synchronizer between tests
+	mockPusher = mocks.NewMockPusherInterface(ctrl)
+	s = NewSynchronizer(WithPusher(mockPusher))
+
+	// a failure to delete from the UPF is a problem too
+	device = BuildSampleDevice()
+	path = BuildRootPath("sample-ent", "sample-site", "slice-id", "slice", "sample-slice")
+	mockPusher.EXPECT().PushDelete("http://5gcore/v1/network-slice/sample-slice").DoAndReturn(func(endpoint string) error {
+		return nil
+	}).AnyTimes()
+	mockPusher.EXPECT().PushDelete("http://upf/v1/config/network-slices/sample-slice").DoAndReturn(func(endpoint string) error {
+		return &PushError{Operation: "DELETE", Endpoint: endpoint, StatusCode: 500, Status: "Internal Server Error"}
+	}).AnyTimes()
+	err = s.HandleDelete(device, path)
+	assert.EqualError(t, err, "Slice sample-slice failed to push delete to UPF: Push Error op=DELETE endpoint=http://upf/v1/config/network-slices/sample-slice code=500 status=Internal Server Error")
+
+	// reset the mockpusher and synchronizer between tests
+	mockPusher = mocks.NewMockPusherInterface(ctrl)
+	s = NewSynchronizer(WithPusher(mockPusher))
+
+	// a UPF without a config endpoint has nothing to delete
+	device = BuildSampleDevice()
+	device.Enterprises.Enterprise["sample-ent"].Site["sample-site"].Upf["sample-upf"].ConfigEndpoint = nil
+	mockPusher.EXPECT().PushDelete("http://5gcore/v1/network-slice/sample-slice").DoAndReturn(func(endpoint string) error {
+		return nil
+	}).AnyTimes()
+	err = s.HandleDelete(device, path)
+	assert.Nil(t, err)
 }
 
 func TestHandleDeleteVCSMissingDeps(t *testing.T) {
@@ -307,6 +342,9 @@ func TestHandleDeleteSlite(t *testing.T) {
 	mockPusher.EXPECT().PushDelete("http://5gcore/v1/network-slice/sample-slice").DoAndReturn(func(endpoint string) error {
 		return nil
 	}).AnyTimes()
+	mockPusher.EXPECT().PushDelete("http://upf/v1/config/network-slices/sample-slice").DoAndReturn(func(endpoint string) error {
+		return nil
+	}).Times(1)
 	err := s.HandleDelete(device, path)
 	assert.Nil(t, err)
 }
@@ -326,6 +364,9 @@ func TestHandleDeleteEnterprise(t *testing.T) {
 	mockPusher.EXPECT().PushDelete("http://5gcore/v1/network-slice/sample-slice").DoAndReturn(func(endpoint string) error {
 		return nil
 	}).AnyTimes()
+	mockPusher.EXPECT().PushDelete("http://upf/v1/config/network-slices/sample-slice").DoAndReturn(func(endpoint string) error {
+		return nil
+	}).Times(1)
 	err := s.HandleDelete(device, path)
 	assert.Nil(t, err)
 }
@@ -0,0 +1,311 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Drift implements detection of changes made to the core behind the synchronizer's back.
+
+package synchronizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/openconfig/ygot/ygot"
+)
+
+// DriftEntry is the result of comparing one object on the core against the model
+type DriftEntry struct {
+	Kind                string     `json:"kind"`
+	ID                  string     `json:"id"`
+	ConnectivityService string     `json:"connectivity-service"`
+	Endpoint            string     `json:"endpoint"`
+	Drifted             bool       `json:"drifted"`
+	Missing             bool       `json:"missing,omitempty"`
+	Diff                []PlanDiff `json:"diff,omitempty"`
+	Repushed            bool       `json:"repushed,omitempty"`
+	Error               string     `json:"error,omitempty"`
+}
+
+// DriftReport is the result of a drift check
+type DriftReport struct {
+	Time    time.Time    `json:"time"`
+	Repush  bool         `json:"repush"`
+	Entries []DriftEntry `json:"entries"`
+	Errors  []string     `json:"errors,omitempty"`
+}
+
+// WithDriftInterval sets the interval between drift checks. Zero disables periodic drift
+// checks.
+func WithDriftInterval(driftInterval time.Duration) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.driftInterval = driftInterval
+	}
+}
+
+// WithDriftRepush sets whether objects that have drifted are pushed again
+func WithDriftRepush(repush bool) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.driftRepush = repush
+	}
+}
+
+// DriftRepush returns true if objects that have drifted are pushed again
+func (s *Synchronizer) DriftRepush() bool {
+	return s.driftRepush
+}
+
+// GetDriftReport returns the report from the most recent drift check, or nil
+func (s *Synchronizer) GetDriftReport() *DriftReport {
+	s.driftMutex.Lock()
+	defer s.driftMutex.Unlock()
+
+	return s.driftReport
+}
+
+// jsonZero returns true if v is the zero value of its JSON type. The core may leave these
+// out of what it returns.
+func jsonZero(v interface{}) bool {
+	switch vv := v.(type) {
+	case nil:
+		return true
+	case string:
+		return vv == ""
+	case float64:
+		return vv == 0
+	case bool:
+		return !vv
+	case []interface{}:
+		return len(vv) == 0
+	case map[string]interface{}:
+		return len(vv) == 0
+	}
+	return false
+}
+
+// subsetDiff appends to diff every leaf of expected that differs in actual. Fields that
+// are in actual but not in expected are ignored, as the core adds fields of its own.
+func subsetDiff(path string, actual interface{}, expected interface{}, diff *[]PlanDiff) {
+	if (actual == nil) && jsonZero(expected) {
+		return
+	}
+
+	switch ev := expected.(type) {
+	case map[string]interface{}:
+		av, okay := actual.(map[string]interface{})
+		if !okay {
+			*diff = append(*diff, PlanDiff{Path: path, Old: actual, New: expected})
+			return
+		}
+		for k, child := range ev {
+			subsetDiff(path+"/"+k, av[k], child, diff)
+		}
+	case []interface{}:
+		av, okay := actual.([]interface{})
+		if !okay || (len(av) != len(ev)) {
+			*diff = append(*diff, PlanDiff{Path: path, Old: actual, New: expected})
+			return
+		}
+		for i, child := range ev {
+			subsetDiff(fmt.Sprintf("%s[%d]", path, i), av[i], child, diff)
+		}
+	default:
+		if !reflect.DeepEqual(actual, expected) {
+			*diff = append(*diff, PlanDiff{Path: path, Old: actual, New: expected})
+		}
+	}
+}
+
+// jsonSubsetDiff compares the document read back from the core against the document that
+// would be pushed, returning the differences sorted by path
+func jsonSubsetDiff(actualData []byte, expectedData []byte) ([]PlanDiff, error) {
+	var actual, expected interface{}
+	if err := json.Unmarshal(actualData, &actual); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(expectedData, &expected); err != nil {
+		return nil, err
+	}
+
+	diff := []PlanDiff{}
+	subsetDiff("", actual, expected, &diff)
+
+	sort.Slice(diff, func(i, j int) bool {
+		return diff[i].Path < diff[j].Path
+	})
+
+	return diff, nil
+}
+
+// updateDriftKpi sets the number of drifted objects of each kind on each connectivity
+// service. The gauge is reset first, so connectivity services that have gone away are
+// not reported.
+func updateDriftKpi(report *DriftReport) {
+	KpiDrift.Reset()
+	for _, entry := range report.Entries {
+		gauge := KpiDrift.WithLabelValues(entry.ConnectivityService, entry.Kind)
+		if entry.Drifted {
+			gauge.Inc()
+		} else {
+			gauge.Add(0)
+		}
+	}
+}
+
+// DriftCheck reads back each device-group and slice from the core, and compares it against
+// what the synchronizer would push for the most recent config. Objects that differ are
+// removed from the cache, so the next synchronization pushes them. If repush is true, they
+// are also pushed immediately.
+func (s *Synchronizer) DriftCheck(repush bool) (*DriftReport, error) {
+	s.reconcileMutex.Lock()
+	config := s.lastConfig
+	s.reconcileMutex.Unlock()
+
+	if config == nil {
+		return nil, fmt.Errorf("No configuration has been received yet")
+	}
+	if s.fetcher == nil {
+		return nil, fmt.Errorf("No fetcher is configured")
+	}
+
+	device, okay := config.(*RootDevice)
+	if !okay {
+		return nil, fmt.Errorf("Configuration is not a RootDevice")
+	}
+
+	report := &DriftReport{Time: time.Now(), Repush: repush, Entries: []DriftEntry{}}
+
+	for _, scope := range deviceScopes(device) {
+		if scope.ConnectivityService.Core_5GEndpoint == nil {
+			continue
+		}
+		coreEndpoint := *scope.ConnectivityService.Core_5GEndpoint
+
+		for _, dg := range scope.Site.DeviceGroup {
+			dgCore, err := s.renderDeviceGroup(scope, dg)
+			if err != nil {
+				report.Errors = append(report.Errors, err.Error())
+				continue
+			}
+			endpoint := fmt.Sprintf("%s/v1/device-group/%s", coreEndpoint, *dg.DeviceGroupId)
+			report.Entries = append(report.Entries, s.driftCheckObject(scope, CacheModelDeviceGroup, *dg.DeviceGroupId, endpoint, dgCore, repush))
+		}
+
+		for _, slice := range scope.Site.Slice {
+			coreSlice, err := s.renderSlice(scope, slice)
+			if err != nil {
+				report.Errors = append(report.Errors, err.Error())
+				continue
+			}
+			endpoint := fmt.Sprintf("%s/v1/network-slice/%s", coreEndpoint, *slice.SliceId)
+			report.Entries = append(report.Entries, s.driftCheckObject(scope, CacheModelSlice, *slice.SliceId, endpoint, coreSlice, repush))
+		}
+	}
+
+	s.cacheFlush()
+
+	sort.Slice(report.Entries, func(i, j int) bool {
+		return statusKey(report.Entries[i].Kind, report.Entries[i].ID, report.Entries[i].ConnectivityService) <
+			statusKey(report.Entries[j].Kind, report.Entries[j].ID, report.Entries[j].ConnectivityService)
+	})
+
+	updateDriftKpi(report)
+
+	s.driftMutex.Lock()
+	s.driftReport = report
+	s.driftMutex.Unlock()
+
+	return report, nil
+}
+
+// driftCheckObject compares one rendered object against what the core has at endpoint. An
+// object is only pushed again if its config is still the latest, and no synchronization
+// pass is running; otherwise the newer config will push it anyway. An object that is in
+// flight or waiting to be retried is left to the retry queue, as pushUpdate does for a pass.
+func (s *Synchronizer) driftCheckObject(scope *AetherScope, kind string, id string, endpoint string, rendered interface{}, repush bool) DriftEntry {
+	cs := *scope.ConnectivityService.ConnectivityServiceId
+	entry := DriftEntry{Kind: kind, ID: id, ConnectivityService: cs, Endpoint: endpoint}
+
+	expected, err := json.MarshalIndent(rendered, "", "  ")
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+
+	actual, err := s.fetcher.Fetch(endpoint)
+	if err != nil {
+		pushError, ok := err.(*PushError)
+		if !ok || pushError.StatusCode != 404 {
+			entry.Error = err.Error()
+			return entry
+		}
+		entry.Missing = true
+		entry.Drifted = true
+	} else {
+		entry.Diff, err = jsonSubsetDiff(actual, expected)
+		if err != nil {
+			entry.Error = fmt.Sprintf("Failed to parse %s: %v", endpoint, err)
+			return entry
+		}
+		entry.Drifted = len(entry.Diff) > 0
+	}
+
+	s.statusDrift(kind, id, cs, endpoint, entry.Drifted)
+	if !entry.Drifted {
+		return entry
+	}
+
+	log.Warnf("%s %s on %s has drifted from the model (missing=%v, differences=%d)", kind, id, cs, entry.Missing, len(entry.Diff))
+
+	// The cache no longer describes what the core has
+	s.CacheDelete(kind, id)
+
+	if repush {
+		s.passMutex.Lock()
+		defer s.passMutex.Unlock()
+
+		s.reconcileMutex.Lock()
+		latest := s.lastConfig == ygot.ValidatedGoStruct(scope.RootDevice)
+		s.reconcileMutex.Unlock()
+		if !latest {
+			log.Infof("%s %s on %s is not pushed again, as a newer config has arrived", kind, id, cs)
+			return entry
+		}
+
+		pushed, err := s.pushUpdate(kind, id, cs, endpoint, expected)
+		if err != nil {
+			entry.Error = err.Error()
+			return entry
+		}
+		if pushed {
+			entry.Repushed = true
+			s.cacheUpdateJSON(kind, id, expected)
+		}
+	}
+
+	return entry
+}
+
+// driftLoop runs a drift check every driftInterval
+func (s *Synchronizer) driftLoop() {
+	log.Infof("Starting drift check loop, interval=%s, repush=%v", s.driftInterval, s.driftRepush)
+	ticker := time.NewTicker(s.driftInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		report, err := s.DriftCheck(s.driftRepush)
+		if err != nil {
+			log.Warnf("Drift check failed: %v", err)
+			continue
+		}
+		drifted := 0
+		for _, entry := range report.Entries {
+			if entry.Drifted {
+				drifted++
+			}
+		}
+		log.Infof("Drift check found %d of %d objects drifted", drifted, len(report.Entries))
+	}
+}
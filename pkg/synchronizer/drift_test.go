@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// renderSample returns the sample device-group and slice as the core would hold them
+func renderSample(t *testing.T, s *Synchronizer, device *RootDevice) (map[string]interface{}, map[string]interface{}) {
+	scope, err := BuildScope(device, "sample-ent", "sample-site", "sample-cs")
+	assert.Nil(t, err)
+
+	dgCore, err := s.renderDeviceGroup(scope, scope.Site.DeviceGroup["sample-dg"])
+	assert.Nil(t, err)
+	sliceCore, err := s.renderSlice(scope, scope.Site.Slice["sample-slice"])
+	assert.Nil(t, err)
+
+	var dgDoc, sliceDoc map[string]interface{}
+	data, _ := json.Marshal(dgCore)
+	assert.Nil(t, json.Unmarshal(data, &dgDoc))
+	data, _ = json.Marshal(sliceCore)
+	assert.Nil(t, json.Unmarshal(data, &sliceDoc))
+
+	return dgDoc, sliceDoc
+}
+
+func TestJSONSubsetDiff(t *testing.T) {
+	// Fields added by the core, and zero values left out by the core, are not drift
+	diff, err := jsonSubsetDiff([]byte(`{"a": 1, "b": {"c": "x"}, "extra": true}`),
+		[]byte(`{"a": 1, "b": {"c": "x", "d": ""}, "e": []}`))
+	assert.Nil(t, err)
+	assert.Empty(t, diff)
+
+	diff, err = jsonSubsetDiff([]byte(`{"a": 2, "b": {"c": "x"}, "l": [1, 2, 3]}`),
+		[]byte(`{"a": 1, "b": {"c": "y"}, "l": [1, 2]}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []PlanDiff{
+		{Path: "/a", Old: float64(2), New: float64(1)},
+		{Path: "/b/c", Old: "x", New: "y"},
+		{Path: "/l", Old: []interface{}{float64(1), float64(2), float64(3)}, New: []interface{}{float64(1), float64(2)}},
+	}, diff)
+}
+
+func TestDriftCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	mockFetcher := mocks.NewMockFetcherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithFetcher(mockFetcher))
+
+	_, err := s.DriftCheck(false)
+	assert.EqualError(t, err, "No configuration has been received yet")
+
+	device := BuildSampleDevice()
+	s.setLastConfig(device)
+	dgDoc, sliceDoc := renderSample(t, s, device)
+
+	// The core adds a field of its own to the device-group, and someone has changed the
+	// slice's site name in the webui
+	dgDoc["created-by"] = "webui"
+	sliceDoc["site-info"].(map[string]interface{})["site-name"] = "edited"
+	dgData, _ := json.Marshal(dgDoc)
+	sliceData, _ := json.Marshal(sliceDoc)
+
+	s.cache[cacheKey(CacheModelDeviceGroup, "sample-dg")] = []byte(`{}`)
+	s.cache[cacheKey(CacheModelSlice, "sample-slice")] = []byte(`{}`)
+
+	mockFetcher.EXPECT().Fetch("http://5gcore/v1/device-group/sample-dg").Return(dgData, nil)
+	mockFetcher.EXPECT().Fetch("http://5gcore/v1/network-slice/sample-slice").Return(sliceData, nil)
+
+	report, err := s.DriftCheck(false)
+	assert.Nil(t, err)
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, 2, len(report.Entries))
+	assert.Equal(t, report, s.GetDriftReport())
+
+	assert.Equal(t, CacheModelDeviceGroup, report.Entries[0].Kind)
+	assert.False(t, report.Entries[0].Drifted)
+
+	assert.Equal(t, CacheModelSlice, report.Entries[1].Kind)
+	assert.True(t, report.Entries[1].Drifted)
+	assert.False(t, report.Entries[1].Repushed)
+	assert.Equal(t, []PlanDiff{{Path: "/site-info/site-name", Old: "edited", New: "sample-site"}}, report.Entries[1].Diff)
+
+	// The drifted slice is dropped from the cache, so the next synchronization pushes it
+	_, okay := s.cache[cacheKey(CacheModelDeviceGroup, "sample-dg")]
+	assert.True(t, okay)
+	_, okay = s.cache[cacheKey(CacheModelSlice, "sample-slice")]
+	assert.False(t, okay)
+
+	status := s.GetStatus(CacheModelSlice, "sample-slice")
+	assert.Equal(t, 1, len(status))
+	assert.True(t, status[0].Drifted)
+	assert.NotNil(t, status[0].LastDriftCheck)
+}
+
+func TestDriftCheckRepush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	mockFetcher := mocks.NewMockFetcherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithFetcher(mockFetcher))
+
+	device := BuildSampleDevice()
+	s.setLastConfig(device)
+	_, sliceDoc := renderSample(t, s, device)
+	sliceData, _ := json.Marshal(sliceDoc)
+
+	// Someone deleted the device-group from the core
+	mockFetcher.EXPECT().Fetch("http://5gcore/v1/device-group/sample-dg").Return(nil, &PushError{StatusCode: 404})
+	mockFetcher.EXPECT().Fetch("http://5gcore/v1/network-slice/sample-slice").Return(sliceData, nil)
+	mockPusher.EXPECT().PushUpdate("http://5gcore/v1/device-group/sample-dg", gomock.Any()).Return(nil)
+
+	report, err := s.DriftCheck(true)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(report.Entries))
+	assert.True(t, report.Entries[0].Drifted)
+	assert.True(t, report.Entries[0].Missing)
+	assert.True(t, report.Entries[0].Repushed)
+	assert.False(t, report.Entries[1].Drifted)
+
+	_, okay := s.cache[cacheKey(CacheModelDeviceGroup, "sample-dg")]
+	assert.True(t, okay)
+
+	// A successful push clears the drift
+	status := s.GetStatus(CacheModelDeviceGroup, "sample-dg")
+	assert.Equal(t, 1, len(status))
+	assert.False(t, status[0].Drifted)
+	assert.Equal(t, StatusSynchronized, status[0].State)
+}
+
+// An object is not pushed again once a newer config has arrived, as that config may change it
+func TestDriftCheckRepushStale(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	mockFetcher := mocks.NewMockFetcherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithFetcher(mockFetcher))
+
+	device := BuildSampleDevice()
+	s.setLastConfig(device)
+	_, sliceDoc := renderSample(t, s, device)
+	sliceData, _ := json.Marshal(sliceDoc)
+
+	mockFetcher.EXPECT().Fetch("http://5gcore/v1/device-group/sample-dg").DoAndReturn(func(endpoint string) ([]byte, error) {
+		s.setLastConfig(BuildSampleDevice())
+		return nil, &PushError{StatusCode: 404}
+	})
+	mockFetcher.EXPECT().Fetch("http://5gcore/v1/network-slice/sample-slice").Return(sliceData, nil)
+
+	report, err := s.DriftCheck(true)
+	assert.Nil(t, err)
+	assert.True(t, report.Entries[0].Drifted)
+	assert.False(t, report.Entries[0].Repushed)
+}
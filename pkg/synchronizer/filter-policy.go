@@ -0,0 +1,195 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Filter policies implement the default-behavior of a slice: the rules that are added to
+// its application filtering rules after those of its applications.
+
+package synchronizer
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// FilterRule is one rule of a filter policy. Endpoint is an IPv4 or IPv6 CIDR, and
+// Action is "permit" or "deny". Protocol and the ports are optional; the ports may only be
+// given with a protocol that has them, or with ANY. A rule is only
+// rendered for slices that have a UE pool in the same address family as its endpoint.
+type FilterRule struct {
+	Name      string
+	Action    string
+	Priority  uint8
+	Endpoint  string
+	Protocol  *string
+	PortStart *uint16 `yaml:"port-start"`
+	PortEnd   *uint16 `yaml:"port-end"`
+}
+
+// FilterPolicy is a named, ordered list of rules, that a slice selects with its
+// default-behavior
+type FilterPolicy struct {
+	Name  string
+	Rules []FilterRule
+}
+
+// FilterPolicyConfig is the contents of a filter policy file
+type FilterPolicyConfig struct {
+	Policies []FilterPolicy
+}
+
+// BuiltinFilterPolicies returns the policies that are available without a policy file.
+// The rules of each address family are numbered from 250, in order, so that they come
+// after the rules of applications; as a rule only matches packets of its own address
+// family, an IPv6 rule shares its priority with the IPv4 rule in the same place. The
+// ALLOW-ALL of ALLOW-PUBLIC is at 254, after the carrier-grade NAT space is denied.
+func BuiltinFilterPolicies() map[string]*FilterPolicy {
+	return map[string]*FilterPolicy{
+		"ALLOW-ALL": {
+			Name: "ALLOW-ALL",
+			Rules: []FilterRule{
+				{Name: "ALLOW-ALL", Action: "permit", Priority: 250, Endpoint: "0.0.0.0/0"},
+				{Name: "ALLOW-ALL-V6", Action: "permit", Priority: 250, Endpoint: "::/0"},
+			},
+		},
+		"DENY-ALL": {
+			Name: "DENY-ALL",
+			Rules: []FilterRule{
+				{Name: "DENY-ALL", Action: "deny", Priority: 250, Endpoint: "0.0.0.0/0"},
+				{Name: "DENY-ALL-V6", Action: "deny", Priority: 250, Endpoint: "::/0"},
+			},
+		},
+		"ALLOW-PUBLIC": {
+			Name: "ALLOW-PUBLIC",
+			Rules: []FilterRule{
+				{Name: "DENY-CLASS-A", Action: "deny", Priority: 250, Endpoint: "10.0.0.0/8"},
+				{Name: "DENY-CLASS-B", Action: "deny", Priority: 251, Endpoint: "172.16.0.0/12"},
+				{Name: "DENY-CLASS-C", Action: "deny", Priority: 252, Endpoint: "192.168.0.0/16"},
+				{Name: "DENY-CGNAT", Action: "deny", Priority: 253, Endpoint: "100.64.0.0/10"},
+				{Name: "ALLOW-ALL", Action: "permit", Priority: 254, Endpoint: "0.0.0.0/0"},
+				{Name: "DENY-ULA", Action: "deny", Priority: 250, Endpoint: "fc00::/7"},
+				{Name: "DENY-LINK-LOCAL", Action: "deny", Priority: 251, Endpoint: "fe80::/10"},
+				{Name: "ALLOW-ALL-V6", Action: "permit", Priority: 252, Endpoint: "::/0"},
+			},
+		},
+	}
+}
+
+// validate returns an error if the policy has no name, has a rule that cannot be rendered,
+// or has two rules of the same address family with the same priority
+func (p *FilterPolicy) validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("Policy has no name")
+	}
+	priorities := map[ipFamily]map[uint8]string{familyIPv4: {}, familyIPv6: {}}
+	for j := range p.Rules {
+		rule := &p.Rules[j]
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("Policy %s: %v", p.Name, err)
+		}
+		if other, okay := priorities[rule.family()][rule.Priority]; okay {
+			return fmt.Errorf("Policy %s: Rule %s has the same priority %d as rule %s", p.Name, rule.Name, rule.Priority, other)
+		}
+		priorities[rule.family()][rule.Priority] = rule.Name
+	}
+	return nil
+}
+
+// validate returns an error if the rule cannot be rendered
+func (r *FilterRule) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("Rule has no name")
+	}
+	if (r.Action != "permit") && (r.Action != "deny") {
+		return fmt.Errorf("Rule %s has invalid action %s; must be permit or deny", r.Name, r.Action)
+	}
+	if _, _, err := parsePrefix(r.Endpoint); err != nil {
+		return fmt.Errorf("Rule %s has invalid endpoint: %v", r.Name, err)
+	}
+	if r.Protocol != nil {
+		if _, err := parseProtocol(*r.Protocol, r.PortStart != nil); err != nil {
+			return fmt.Errorf("Rule %s: %v", r.Name, err)
+		}
+	}
+	if (r.PortStart == nil) && (r.PortEnd != nil) {
+		return fmt.Errorf("Rule %s has port-end without port-start", r.Name)
+	}
+	if (r.PortStart != nil) && (r.PortEnd != nil) && (*r.PortStart > *r.PortEnd) {
+		return fmt.Errorf("Rule %s has port-start %d after port-end %d", r.Name, *r.PortStart, *r.PortEnd)
+	}
+	return nil
+}
+
+// family returns the address family of the rule's endpoint
+func (r *FilterRule) family() ipFamily {
+	return prefixFamily(r.Endpoint)
+}
+
+// render converts the rule into an application filtering rule for the core
+func (r *FilterRule) render(s *Synchronizer) appFilterRule {
+	rule := appFilterRule{
+		Name:     r.Name,
+		Action:   r.Action,
+		Priority: s.mapPriority(r.Priority),
+		Endpoint: r.Endpoint,
+	}
+	if r.Protocol != nil {
+		// validate has already checked the protocol
+		rule.Protocol, _ = parseProtocol(*r.Protocol, r.PortStart != nil)
+	}
+	if r.PortStart != nil {
+		rule.DestPortStart = r.PortStart
+		if r.PortEnd != nil {
+			rule.DestPortEnd = r.PortEnd
+		} else {
+			// no PortEnd specified -- assume it's a singleton range
+			rule.DestPortEnd = r.PortStart
+		}
+	}
+	return rule
+}
+
+// LoadFilterPolicies reads filter policies from a YAML file, checking that each of them
+// can be rendered, and that no two rules of one address family in a policy have the same
+// priority
+func LoadFilterPolicies(fn string) (map[string]*FilterPolicy, error) {
+	yamlFile, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read yaml file: %v", err)
+	}
+
+	config := FilterPolicyConfig{}
+	err = yaml.UnmarshalStrict(yamlFile, &config)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal yaml: %v", err)
+	}
+
+	policies := map[string]*FilterPolicy{}
+	for i := range config.Policies {
+		policy := &config.Policies[i]
+		if policy.Name == "" {
+			return nil, fmt.Errorf("Policy %d has no name", i)
+		}
+		if _, okay := policies[policy.Name]; okay {
+			return nil, fmt.Errorf("Policy %s is defined more than once", policy.Name)
+		}
+		if err := policy.validate(); err != nil {
+			return nil, err
+		}
+		policies[policy.Name] = policy
+	}
+
+	return policies, nil
+}
+
+// WithFilterPolicies adds filter policies to the built-in ones. A policy with the same
+// name as a built-in policy replaces it.
+func WithFilterPolicies(policies map[string]*FilterPolicy) SynchronizerOption {
+	return func(s *Synchronizer) {
+		for name, policy := range policies {
+			s.filterPolicies[name] = policy
+		}
+	}
+}
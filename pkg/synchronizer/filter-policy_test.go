@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadFilterPolicies(t *testing.T) {
+	policies, err := LoadFilterPolicies("./testdata/sample-filter-policies.yaml")
+	assert.Nil(t, err)
+	assert.Len(t, policies, 2)
+	assert.Equal(t, FilterRule{
+		Name:      "ALLOW-ONPREM-HTTPS",
+		Action:    "permit",
+		Priority:  249,
+		Endpoint:  "10.20.0.0/16",
+		Protocol:  aStr("TCP"),
+		PortStart: aUint16(443),
+	}, policies["ALLOW-ONPREM"].Rules[0])
+
+	_, err = LoadFilterPolicies("./testdata/missing.yaml")
+	assert.NotNil(t, err)
+
+	policies, err = LoadFilterPolicies("../../examples/sample-filter-policies.yaml")
+	assert.Nil(t, err)
+	assert.Len(t, policies, 2)
+}
+
+func TestBuiltinFilterPolicies(t *testing.T) {
+	// The default-behavior comes after the rules of applications
+	for name, policy := range BuiltinFilterPolicies() {
+		assert.Nil(t, policy.validate(), name)
+		for _, rule := range policy.Rules {
+			assert.GreaterOrEqual(t, rule.Priority, uint8(250), rule.Name)
+		}
+	}
+
+	// ALLOW-PUBLIC denies carrier-grade NAT space as well as RFC 1918 space
+	assert.Contains(t, BuiltinFilterPolicies()["ALLOW-PUBLIC"].Rules,
+		FilterRule{Name: "DENY-CGNAT", Action: "deny", Priority: 253, Endpoint: "100.64.0.0/10"})
+}
+
+func TestLoadFilterPoliciesInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filter-policy")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	fn := filepath.Join(dir, "policies.yaml")
+
+	tests := []struct {
+		yaml string
+		err  string
+	}{
+		{"policies:\n  - rules: []\n", "Policy 0 has no name"},
+		{"policies:\n  - name: A\n  - name: A\n", "Policy A is defined more than once"},
+		{"policies:\n  - name: A\n    rules:\n      - {name: r, action: allow, endpoint: 0.0.0.0/0}\n",
+			"Policy A: Rule r has invalid action allow; must be permit or deny"},
+		{"policies:\n  - name: A\n    rules:\n      - {name: r, action: deny, endpoint: 10.0.0.0}\n",
+			"Policy A: Rule r has invalid endpoint: 10.0.0.0 is not a prefix"},
+		{"policies:\n  - name: A\n    rules:\n      - {name: r, action: deny, endpoint: \"::ffff:10.0.0.0/104\"}\n",
+			"Policy A: Rule r has invalid endpoint: ::ffff:10.0.0.0 mixes IPv4 and IPv6"},
+		{"policies:\n  - name: A\n    rules:\n      - {name: r, action: deny, endpoint: 0.0.0.0/0, protocol: MQTT}\n",
+			"Policy A: Rule r: Unknown protocol MQTT"},
+		{"policies:\n  - name: A\n    rules:\n      - {name: r, action: deny, endpoint: 0.0.0.0/0, protocol: ICMP, port-start: 80}\n",
+			"Policy A: Rule r: Protocol ICMP has no ports, but a port range is given"},
+		{"policies:\n  - name: A\n    rules:\n      - {name: r, action: deny, endpoint: 0.0.0.0/0, port-start: 90, port-end: 80}\n",
+			"Policy A: Rule r has port-start 90 after port-end 80"},
+		{"policies:\n  - name: A\n    rules:\n      - {name: r, action: deny, priority: 250, endpoint: fc00::/7}\n      - {name: s, action: deny, priority: 250, endpoint: fe80::/10}\n",
+			"Policy A: Rule s has the same priority 250 as rule r"},
+	}
+
+	for _, test := range tests {
+		assert.Nil(t, ioutil.WriteFile(fn, []byte(test.yaml), 0644))
+		_, err = LoadFilterPolicies(fn)
+		assert.EqualError(t, err, test.err)
+	}
+}
+
+func TestRenderSliceFilterPolicy(t *testing.T) {
+	policies, err := LoadFilterPolicies("./testdata/sample-filter-policies.yaml")
+	assert.Nil(t, err)
+
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithFilterPolicies(policies))
+
+	device := BuildSampleDevice()
+	scope, err := BuildScope(device, "sample-ent", "sample-site", "sample-cs")
+	assert.Nil(t, err)
+	slice := scope.Site.Slice["sample-slice"]
+	slice.Filter = nil
+
+	slice.DefaultBehavior = aStr("ALLOW-ONPREM")
+	coreSlice, err := s.renderSlice(scope, slice)
+	assert.Nil(t, err)
+	// The sample UE pool is IPv4, so the IPv6 rule is left out
+	assert.Equal(t, []appFilterRule{
+		{Name: "ALLOW-ONPREM-HTTPS", Action: "permit", Priority: 249, Endpoint: "10.20.0.0/16", Protocol: aUint8(6), DestPortStart: aUint16(443), DestPortEnd: aUint16(443)},
+		{Name: "DENY-CLASS-A", Action: "deny", Priority: 250, Endpoint: "10.0.0.0/8"},
+	}, coreSlice.ApplicationFilteringRules)
+
+	// A policy from the file replaces the built-in one of the same name
+	slice.DefaultBehavior = aStr("DENY-ALL")
+	coreSlice, err = s.renderSlice(scope, slice)
+	assert.Nil(t, err)
+	assert.Equal(t, []appFilterRule{{Name: "DENY-ALL-V4", Action: "deny", Priority: 250, Endpoint: "0.0.0.0/0"}}, coreSlice.ApplicationFilteringRules)
+
+	// The other built-in policies are still there
+	slice.DefaultBehavior = aStr("ALLOW-ALL")
+	coreSlice, err = s.renderSlice(scope, slice)
+	assert.Nil(t, err)
+	assert.Equal(t, []appFilterRule{{Name: "ALLOW-ALL", Action: "permit", Priority: 250, Endpoint: "0.0.0.0/0"}}, coreSlice.ApplicationFilteringRules)
+
+	slice.DefaultBehavior = aStr("UNKNOWN")
+	_, err = s.renderSlice(scope, slice)
+	assert.EqualError(t, err, "Slice sample-slice has invalid defauilt-behavior UNKNOWN")
+}
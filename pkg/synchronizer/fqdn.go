@@ -0,0 +1,357 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// FQDN implements applications whose address lists several addresses, prefixes or host
+// names. Host names are resolved into host prefixes, which are refreshed on a schedule.
+// Lookups are only done by the FQDN loop, as rendering may hold up a Set.
+
+package synchronizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// A host name is dot-separated labels of letters, digits and hyphens, with an optional
+// trailing dot. The last label starts with a letter, so that a malformed IPv4 address is
+// not mistaken for one.
+var fqdnRegexp = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?\.)*[A-Za-z]([A-Za-z0-9-]*[A-Za-z0-9])?\.?$`)
+
+// fqdnKeepRefreshes is the number of refreshes that an address is kept for after a host
+// name stops resolving to it. A host name whose answers rotate through a pool of addresses
+// then settles on the whole pool, rather than synchronizing again on every refresh.
+const fqdnKeepRefreshes = 3
+
+// fqdnPendingError is the error of rendering an application whose host name has not been
+// resolved yet. The FQDN loop resolves it, and synchronizes again.
+type fqdnPendingError struct {
+	host string
+}
+
+func (e *fqdnPendingError) Error() string {
+	return fmt.Sprintf("%s has not been resolved yet", e.host)
+}
+
+// isFQDNPending returns true if err is caused by a host name that has not been resolved yet
+func isFQDNPending(err error) bool {
+	var pending *fqdnPendingError
+	return errors.As(err, &pending)
+}
+
+// DNSResolver resolves host names using the system resolver
+type DNSResolver struct {
+	timeout time.Duration
+}
+
+// NewDNSResolver creates a DNSResolver that gives up on a lookup after timeout
+func NewDNSResolver(timeout time.Duration) *DNSResolver {
+	return &DNSResolver{timeout: timeout}
+}
+
+// LookupHost returns the IPv4 and IPv6 addresses of host
+func (r *DNSResolver) LookupHost(host string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+	return net.DefaultResolver.LookupHost(ctx, host)
+}
+
+// WithResolver sets the resolver for the host names in application addresses
+func WithResolver(resolver ResolverInterface) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.resolver = resolver
+	}
+}
+
+// WithFQDNRefreshInterval sets the interval between resolving the host names in application
+// addresses again. Zero disables refreshing.
+func WithFQDNRefreshInterval(interval time.Duration) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.fqdnRefreshInterval = interval
+	}
+}
+
+// splitAddresses splits an application address into the addresses, prefixes and host names
+// that it lists, separated by commas or spaces
+func splitAddresses(address string) []string {
+	return strings.FieldsFunc(address, func(r rune) bool {
+		return (r == ',') || unicode.IsSpace(r)
+	})
+}
+
+// isFQDN returns true if s is a host name rather than an address or prefix
+func isFQDN(s string) bool {
+	return fqdnRegexp.MatchString(s)
+}
+
+// lookupFQDN resolves a host name into a sorted list of host prefixes
+func (s *Synchronizer) lookupFQDN(host string) ([]string, error) {
+	addrs, err := s.resolver.LookupHost(host)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to resolve %s: %v", host, err)
+	}
+
+	seen := map[string]bool{}
+	prefixes := []string{}
+	for _, addr := range addrs {
+		prefix, _, err := hostPrefix(addr)
+		if err != nil {
+			log.Warnf("Ignoring address %s of %s: %v", addr, host, err)
+			continue
+		}
+		if !seen[prefix] {
+			seen[prefix] = true
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if len(prefixes) == 0 {
+		return nil, fmt.Errorf("%s resolved to no addresses", host)
+	}
+
+	sort.Strings(prefixes)
+	return prefixes, nil
+}
+
+// resolveFQDN returns the host prefixes of a host name from the answers of the FQDN loop,
+// so that every slice that uses the host name renders it the same way. A host name that
+// has no answer is handed to the FQDN loop, which synchronizes again once it has one.
+func (s *Synchronizer) resolveFQDN(host string) ([]string, error) {
+	s.fqdnMutex.Lock()
+	defer s.fqdnMutex.Unlock()
+
+	if prefixes, okay := s.fqdnAnswers[host]; okay {
+		return prefixes, nil
+	}
+
+	s.fqdnPending[host] = true
+	select {
+	case s.fqdnWakeChannel <- struct{}{}:
+	default:
+	}
+
+	if err, okay := s.fqdnErrors[host]; okay {
+		return nil, err
+	}
+	return nil, &fqdnPendingError{host: host}
+}
+
+// fqdnMerge merges a new answer for host into the addresses that it is rendered with, and
+// returns true if they changed. Addresses that are not in the answer are dropped after
+// fqdnKeepRefreshes answers without them. Caller must hold fqdnMutex.
+func (s *Synchronizer) fqdnMerge(host string, prefixes []string) bool {
+	misses, okay := s.fqdnMisses[host]
+	if !okay {
+		misses = map[string]int{}
+		for _, prefix := range s.fqdnAnswers[host] {
+			misses[prefix] = 0
+		}
+	}
+
+	current := map[string]bool{}
+	for _, prefix := range prefixes {
+		current[prefix] = true
+		misses[prefix] = 0
+	}
+	merged := []string{}
+	for prefix := range misses {
+		if !current[prefix] {
+			misses[prefix]++
+			if misses[prefix] >= fqdnKeepRefreshes {
+				delete(misses, prefix)
+				continue
+			}
+		}
+		merged = append(merged, prefix)
+	}
+	sort.Strings(merged)
+
+	old, had := s.fqdnAnswers[host]
+	s.fqdnAnswers[host] = merged
+	s.fqdnMisses[host] = misses
+	delete(s.fqdnErrors, host)
+
+	if had && reflect.DeepEqual(old, merged) {
+		return false
+	}
+	log.Infof("%s now resolves to %s", host, strings.Join(merged, ", "))
+	return true
+}
+
+// applicationPrefixes returns the prefixes that the rules of an application are rendered
+// for. The caller has checked that the application has an address. The prefixes that a
+// host name resolves to are limited to the slice's address families, as host names
+// commonly have both IPv4 and IPv6 addresses. A literal address is given on purpose, so
+// one that is not in the slice's address families is an error.
+func (s *Synchronizer) applicationPrefixes(app *Application, families map[ipFamily]bool) ([]string, error) {
+	prefixes := []string{}
+	seen := map[string]bool{}
+	add := func(prefix string) {
+		if !seen[prefix] {
+			seen[prefix] = true
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	for _, address := range splitAddresses(*app.Address) {
+		if isFQDN(address) {
+			resolved, err := s.resolveFQDN(address)
+			if err != nil {
+				return nil, err
+			}
+			for _, prefix := range resolved {
+				if families[prefixFamily(prefix)] {
+					add(prefix)
+				}
+			}
+			continue
+		}
+
+		prefix, family, err := hostPrefix(address)
+		if err != nil {
+			return nil, err
+		}
+		if !families[family] {
+			return nil, fmt.Errorf("%s is %s, but the slice has no %s UE pool", address, family, family)
+		}
+		add(prefix)
+	}
+
+	if len(prefixes) == 0 {
+		return nil, fmt.Errorf("%s has no addresses in the address families of the slice", *app.Address)
+	}
+	return prefixes, nil
+}
+
+// refreshFQDNs resolves the host names of the applications in the most recent config
+// again. If the addresses of any of them have changed, the latest config is synchronized
+// again, so that the slices that use the host name are pushed with the new prefixes. A host
+// name that fails to resolve keeps its previous answer.
+func (s *Synchronizer) refreshFQDNs() {
+	s.reconcileMutex.Lock()
+	config := s.lastConfig
+	s.reconcileMutex.Unlock()
+
+	device, okay := config.(*RootDevice)
+	if !okay || (device.Enterprises == nil) {
+		return
+	}
+
+	hosts := map[string]bool{}
+	for _, enterprise := range device.Enterprises.Enterprise {
+		for _, app := range enterprise.Application {
+			if app.Address == nil {
+				continue
+			}
+			for _, address := range splitAddresses(*app.Address) {
+				if isFQDN(address) {
+					hosts[address] = true
+				}
+			}
+		}
+	}
+
+	answers := map[string][]string{}
+	lookupErrors := map[string]error{}
+	for host := range hosts {
+		prefixes, err := s.lookupFQDN(host)
+		if err != nil {
+			log.Warnf("%v", err)
+			lookupErrors[host] = err
+			continue
+		}
+		answers[host] = prefixes
+	}
+
+	s.fqdnMutex.Lock()
+	// Host names that are no longer used are dropped
+	for host := range s.fqdnAnswers {
+		if !hosts[host] {
+			delete(s.fqdnAnswers, host)
+			delete(s.fqdnMisses, host)
+		}
+	}
+	for host := range s.fqdnErrors {
+		if !hosts[host] {
+			delete(s.fqdnErrors, host)
+		}
+	}
+
+	changed := false
+	for host, err := range lookupErrors {
+		if _, had := s.fqdnAnswers[host]; !had {
+			s.fqdnErrors[host] = err
+		}
+	}
+	for host, prefixes := range answers {
+		if s.fqdnMerge(host, prefixes) {
+			changed = true
+		}
+	}
+	s.fqdnMutex.Unlock()
+
+	// The config may have been replaced by a Set during the lookups, so the latest one is
+	// synchronized rather than the one that was resolved
+	if changed {
+		s.requestResync()
+	}
+}
+
+// resolvePendingFQDNs resolves the host names that slices were rendered without, and
+// synchronizes again if any of them now has an answer, or has failed for the first time
+// so that its slices report the failure
+func (s *Synchronizer) resolvePendingFQDNs() {
+	s.fqdnMutex.Lock()
+	pending := s.fqdnPending
+	s.fqdnPending = map[string]bool{}
+	s.fqdnMutex.Unlock()
+
+	changed := false
+	for host := range pending {
+		prefixes, err := s.lookupFQDN(host)
+
+		s.fqdnMutex.Lock()
+		if err != nil {
+			log.Warnf("%v", err)
+			if _, failed := s.fqdnErrors[host]; !failed {
+				changed = true
+			}
+			s.fqdnErrors[host] = err
+		} else if s.fqdnMerge(host, prefixes) {
+			changed = true
+		}
+		s.fqdnMutex.Unlock()
+	}
+
+	if changed {
+		s.requestResync()
+	}
+}
+
+// fqdnLoop resolves the host names that slices are waiting for, and resolves the host names
+// of applications again every fqdnRefreshInterval
+func (s *Synchronizer) fqdnLoop() {
+	log.Infof("Starting FQDN loop, refresh interval=%s", s.fqdnRefreshInterval)
+	var refresh <-chan time.Time
+	if s.fqdnRefreshInterval > 0 {
+		ticker := time.NewTicker(s.fqdnRefreshInterval)
+		defer ticker.Stop()
+		refresh = ticker.C
+	}
+
+	for {
+		select {
+		case <-refresh:
+			s.refreshFQDNs()
+		case <-s.fqdnWakeChannel:
+			s.resolvePendingFQDNs()
+		}
+	}
+}
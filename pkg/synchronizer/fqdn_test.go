@@ -0,0 +1,189 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSplitAddresses(t *testing.T) {
+	assert.Equal(t, []string{"1.2.3.4", "10.0.0.0/8", "saas.example.com"}, splitAddresses("1.2.3.4, 10.0.0.0/8 saas.example.com"))
+	assert.Empty(t, splitAddresses(""))
+
+	assert.True(t, isFQDN("saas.example.com"))
+	assert.True(t, isFQDN("saas.example.com."))
+	assert.False(t, isFQDN("1.2.3.999"))
+	assert.False(t, isFQDN("2001:db8::1"))
+}
+
+func TestRenderSliceMultipleAddresses(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	mockResolver := mocks.NewMockResolverInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithResolver(mockResolver))
+
+	device := BuildSampleDevice()
+	scope, err := BuildScope(device, "sample-ent", "sample-site", "sample-cs")
+	assert.Nil(t, err)
+	slice := scope.Site.Slice["sample-slice"]
+	slice.Filter = map[string]*SliceFilter{"sample-app": slice.Filter["sample-app"]}
+	app := scope.Enterprise.Application["sample-app"]
+
+	// The IPv6 address of the host name is left out of the IPv4 slice, and the answer is
+	// only looked up once
+	app.Address = aStr("1.2.3.4, 10.0.0.0/8 saas.example.com")
+	_, err = s.renderSlice(scope, slice)
+	assert.EqualError(t, err, "Slice sample-slice Application sample-app has invalid address: saas.example.com has not been resolved yet")
+	assert.True(t, isFQDNPending(err))
+	mockResolver.EXPECT().LookupHost("saas.example.com").Return([]string{"5.6.7.8", "2001:db8::1", "5.6.7.8"}, nil).Times(1)
+	s.resolvePendingFQDNs()
+	for i := 0; i < 2; i++ {
+		coreSlice, err := s.renderSlice(scope, slice)
+		assert.Nil(t, err)
+		rules := coreSlice.ApplicationFilteringRules
+		assert.Len(t, rules, 4)
+		assert.Equal(t, []string{"sample-app-sample-app-ep-1", "sample-app-sample-app-ep-2", "sample-app-sample-app-ep-3"},
+			[]string{rules[0].Name, rules[1].Name, rules[2].Name})
+		assert.Equal(t, []string{"1.2.3.4/32", "10.0.0.0/8", "5.6.7.8/32"},
+			[]string{rules[0].Endpoint, rules[1].Endpoint, rules[2].Endpoint})
+		assert.Equal(t, rules[0].DestPortStart, rules[2].DestPortStart)
+	}
+
+	// An application with no address is rejected, rather than matching every destination
+	app.Address = nil
+	_, err = s.renderSlice(scope, slice)
+	assert.EqualError(t, err, "Slice sample-slice Application sample-app has empty address")
+	app.Address = aStr(" , ")
+	_, err = s.renderSlice(scope, slice)
+	assert.EqualError(t, err, "Slice sample-slice Application sample-app has empty address")
+
+	// A host name that failed to resolve is tried again when it is next rendered
+	app.Address = aStr("down.example.com")
+	mockResolver.EXPECT().LookupHost("down.example.com").Return(nil, errors.New("no such host")).Times(2)
+	_, err = s.renderSlice(scope, slice)
+	assert.True(t, isFQDNPending(err))
+	s.resolvePendingFQDNs()
+	_, err = s.renderSlice(scope, slice)
+	assert.EqualError(t, err, "Slice sample-slice Application sample-app has invalid address: Failed to resolve down.example.com: no such host")
+	assert.False(t, isFQDNPending(err))
+
+	app.Address = aStr("v6only.example.com")
+	mockResolver.EXPECT().LookupHost("v6only.example.com").Return([]string{"2001:db8::2"}, nil)
+	_, err = s.renderSlice(scope, slice)
+	assert.True(t, isFQDNPending(err))
+	s.resolvePendingFQDNs()
+	_, err = s.renderSlice(scope, slice)
+	assert.EqualError(t, err, "Slice sample-slice Application sample-app has invalid address: v6only.example.com has no addresses in the address families of the slice")
+}
+
+func TestRefreshFQDNs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	mockResolver := mocks.NewMockResolverInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithResolver(mockResolver))
+
+	// Nothing to refresh before a config has been received
+	s.refreshFQDNs()
+
+	device := BuildSampleDevice()
+	device.Enterprises.Enterprise["sample-ent"].Application["sample-app"].Address = aStr("saas.example.com")
+	s.setLastConfig(device)
+	s.fqdnAnswers["saas.example.com"] = []string{"5.6.7.8/32"}
+	s.fqdnAnswers["unused.example.com"] = []string{"9.9.9.9/32"}
+
+	// An unchanged answer does not synchronize again, and unused host names are dropped
+	mockResolver.EXPECT().LookupHost("saas.example.com").Return([]string{"5.6.7.8"}, nil)
+	s.refreshFQDNs()
+	assert.Len(t, s.updateChannel, 0)
+	assert.Equal(t, map[string][]string{"saas.example.com": {"5.6.7.8/32"}}, s.fqdnAnswers)
+
+	// A failed lookup keeps the previous answer
+	mockResolver.EXPECT().LookupHost("saas.example.com").Return(nil, errors.New("timeout"))
+	s.refreshFQDNs()
+	assert.Len(t, s.updateChannel, 0)
+	assert.Equal(t, []string{"5.6.7.8/32"}, s.fqdnAnswers["saas.example.com"])
+
+	// A changed answer synchronizes the config again
+	mockResolver.EXPECT().LookupHost("saas.example.com").Return([]string{"5.6.7.9", "5.6.7.8"}, nil)
+	s.refreshFQDNs()
+	assert.Equal(t, []string{"5.6.7.8/32", "5.6.7.9/32"}, s.fqdnAnswers["saas.example.com"])
+	assert.Len(t, s.updateChannel, 1)
+	update := <-s.updateChannel
+	assert.Equal(t, device, update.config)
+	assert.Nil(t, update.path)
+
+	// An update that is already pending is synchronized in full, rather than being replaced
+	// by the config that was resolved
+	newer := BuildSampleDevice()
+	s.updateChannel <- &ConfigUpdate{config: newer, callbackType: gnmi.Apply, path: &pb.Path{Elem: []*pb.PathElem{{Name: "site"}}}}
+	mockResolver.EXPECT().LookupHost("saas.example.com").Return([]string{"5.6.7.10"}, nil)
+	s.refreshFQDNs()
+	assert.Len(t, s.updateChannel, 1)
+	update = <-s.updateChannel
+	assert.Equal(t, newer, update.config)
+	assert.Nil(t, update.path)
+}
+
+// A host name whose answers rotate through a pool settles on the whole pool, and an address
+// is only dropped after it has been missing from several answers
+func TestRefreshFQDNsRotating(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	mockResolver := mocks.NewMockResolverInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithResolver(mockResolver))
+
+	device := BuildSampleDevice()
+	device.Enterprises.Enterprise["sample-ent"].Application["sample-app"].Address = aStr("cdn.example.com")
+	s.setLastConfig(device)
+
+	refresh := func(addrs ...string) bool {
+		mockResolver.EXPECT().LookupHost("cdn.example.com").Return(addrs, nil)
+		s.refreshFQDNs()
+		select {
+		case <-s.updateChannel:
+			return true
+		default:
+			return false
+		}
+	}
+
+	assert.True(t, refresh("5.6.7.8"))
+	assert.True(t, refresh("5.6.7.9"))
+	assert.Equal(t, []string{"5.6.7.8/32", "5.6.7.9/32"}, s.fqdnAnswers["cdn.example.com"])
+	assert.False(t, refresh("5.6.7.8"))
+	assert.False(t, refresh("5.6.7.9"))
+	assert.False(t, refresh("5.6.7.9"))
+	assert.True(t, refresh("5.6.7.9"))
+	assert.Equal(t, []string{"5.6.7.9/32"}, s.fqdnAnswers["cdn.example.com"])
+}
+
+// A slice whose host name has not been resolved is not a failed push, and is synchronized
+// again once the host name is resolved
+func TestSynchronizeFQDNPending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	mockResolver := mocks.NewMockResolverInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithResolver(mockResolver))
+
+	device := BuildSampleDevice()
+	device.Enterprises.Enterprise["sample-ent"].Application["sample-app"].Address = aStr("saas.example.com")
+	s.setLastConfig(device)
+
+	mockPusher.EXPECT().PushUpdate("http://5gcore/v1/device-group/sample-dg", gomock.Any()).Return(nil)
+	pushErrors, objErrors, err := s.synchronizeDevice(device, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, pushErrors)
+	assert.Empty(t, objErrors)
+
+	mockResolver.EXPECT().LookupHost("saas.example.com").Return([]string{"5.6.7.8"}, nil)
+	s.resolvePendingFQDNs()
+	assert.Len(t, s.updateChannel, 1)
+}
@@ -0,0 +1,33 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Impact implements analysis of what depends on an object in the model.
+
+package synchronizer
+
+import (
+	"fmt"
+
+	"github.com/onosproject/sdcore-adapter/pkg/refgraph"
+)
+
+// Impact returns the objects in the most recent config that depend on node, and the
+// device-groups and slices that would be pushed again if it changed. The node does not need
+// to exist, so that objects referring to a missing node can be found.
+func (s *Synchronizer) Impact(node refgraph.NodeID) (*refgraph.Impact, error) {
+	s.reconcileMutex.Lock()
+	config := s.lastConfig
+	s.reconcileMutex.Unlock()
+
+	if config == nil {
+		return nil, fmt.Errorf("No configuration has been received yet")
+	}
+
+	device, okay := config.(*RootDevice)
+	if !okay {
+		return nil, fmt.Errorf("Configuration is not a RootDevice")
+	}
+
+	return s.buildGraph(device).Impact(node), nil
+}
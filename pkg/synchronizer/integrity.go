@@ -0,0 +1,189 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Integrity implements checks of the references and shared resources of a whole config,
+// so that a Set that could never be synchronized is rejected before it is accepted.
+
+package synchronizer
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/onosproject/sdcore-adapter/pkg/refgraph"
+	"github.com/openconfig/ygot/ygot"
+)
+
+// ValidationError lists every rule that a config breaks
+type ValidationError struct {
+	Violations []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%d violations: %s", len(e.Violations), strings.Join(e.Violations, "; "))
+}
+
+// violations is a set of messages, so that a rule broken on several connectivity services
+// is reported once
+type violations map[string]bool
+
+func (v violations) add(format string, args ...interface{}) {
+	v[fmt.Sprintf(format, args...)] = true
+}
+
+// ValidateConfig checks the referential integrity of a config. It is meant to be used as
+// the gNMI server's validator, and returns a *ValidationError listing every violation.
+func ValidateConfig(config ygot.ValidatedGoStruct) error {
+	device, okay := config.(*RootDevice)
+	if !okay {
+		return nil
+	}
+
+	found := violations{}
+
+	graph := refgraph.Build(device)
+	for _, ref := range graph.Dangling() {
+		found.add("%s refers to missing %s", ref.From, ref.To)
+	}
+
+	validateDisabled(device, graph, found)
+
+	// IMSIs and UE subnets must be unique among everything pushed to the same core
+	scopesByCs := map[string][]*AetherScope{}
+	for _, scope := range deviceScopes(device) {
+		csID := *scope.ConnectivityService.ConnectivityServiceId
+		scopesByCs[csID] = append(scopesByCs[csID], scope)
+	}
+	for _, scopes := range scopesByCs {
+		sortScopes(scopes)
+		validateImsis(scopes, found)
+		validateSubnets(scopes, found)
+	}
+
+	if len(found) == 0 {
+		return nil
+	}
+
+	validationErr := &ValidationError{}
+	for msg := range found {
+		validationErr.Violations = append(validationErr.Violations, msg)
+	}
+	sort.Strings(validationErr.Violations)
+	return validationErr
+}
+
+// sortScopes orders scopes by enterprise and site, so that violations are reported the
+// same way each time
+func sortScopes(scopes []*AetherScope) {
+	sort.Slice(scopes, func(i, j int) bool {
+		if *scopes[i].Enterprise.EnterpriseId != *scopes[j].Enterprise.EnterpriseId {
+			return *scopes[i].Enterprise.EnterpriseId < *scopes[j].Enterprise.EnterpriseId
+		}
+		return *scopes[i].Site.SiteId < *scopes[j].Site.SiteId
+	})
+}
+
+// validateDisabled finds disabled objects that other objects still refer to. An ip-domain
+// is disabled when its admin-status is anything other than DefaultAdminStatus.
+func validateDisabled(device *RootDevice, graph *refgraph.Graph, found violations) {
+	if device.Enterprises == nil {
+		return
+	}
+	for entID, enterprise := range device.Enterprises.Enterprise {
+		for siteID, site := range enterprise.Site {
+			for ipdID, ipd := range site.IpDomain {
+				if (ipd.AdminStatus == nil) || (*ipd.AdminStatus == DefaultAdminStatus) {
+					continue
+				}
+				node := refgraph.NodeID{Kind: refgraph.KindIPDomain, Enterprise: entID, Site: siteID, ID: ipdID}
+				for _, referrer := range graph.Referrers(node) {
+					found.add("%s refers to %s, whose admin-status is %s", referrer, node, *ipd.AdminStatus)
+				}
+			}
+		}
+	}
+}
+
+// validateImsis finds IMSIs that are in more than one device-group on the same core
+func validateImsis(scopes []*AetherScope, found violations) {
+	imsiOwner := map[uint64]string{}
+	for _, scope := range scopes {
+		site := scope.Site
+		if site.ImsiDefinition == nil {
+			continue
+		}
+		// be deterministic...
+		dgIDs := []string{}
+		for dgID := range site.DeviceGroup {
+			dgIDs = append(dgIDs, dgID)
+		}
+		sort.Strings(dgIDs)
+
+		for _, dgID := range dgIDs {
+			owner := fmt.Sprintf("device-group %s/%s/%s", *scope.Enterprise.EnterpriseId, *site.SiteId, dgID)
+			for _, dgDevice := range site.DeviceGroup[dgID].Device {
+				if ((dgDevice.Enable != nil) && !*dgDevice.Enable) || (dgDevice.DeviceId == nil) {
+					continue
+				}
+				device, okay := site.Device[*dgDevice.DeviceId]
+				if !okay || (device.SimCard == nil) {
+					continue
+				}
+				simCard, okay := site.SimCard[*device.SimCard]
+				if !okay || (simCard.Imsi == nil) {
+					continue
+				}
+				imsi, err := FormatImsiDef(site.ImsiDefinition, *simCard.Imsi)
+				if err != nil {
+					// Reported when the device-group is synchronized
+					continue
+				}
+				if other, okay := imsiOwner[imsi]; okay && (other != owner) {
+					found.add("IMSI %015d is in both %s and %s", imsi, other, owner)
+					continue
+				}
+				imsiOwner[imsi] = owner
+			}
+		}
+	}
+}
+
+// validateSubnets finds ip-domains on the same core whose UE subnets overlap
+func validateSubnets(scopes []*AetherScope, found violations) {
+	type subnet struct {
+		owner string
+		net   *net.IPNet
+	}
+	subnets := []subnet{}
+	for _, scope := range scopes {
+		site := scope.Site
+		// be deterministic...
+		ipdIDs := []string{}
+		for ipdID := range site.IpDomain {
+			ipdIDs = append(ipdIDs, ipdID)
+		}
+		sort.Strings(ipdIDs)
+
+		for _, ipdID := range ipdIDs {
+			ipd := site.IpDomain[ipdID]
+			if ipd.Subnet == nil {
+				continue
+			}
+			owner := fmt.Sprintf("ip-domain %s/%s/%s", *scope.Enterprise.EnterpriseId, *site.SiteId, ipdID)
+			_, ipNet, err := net.ParseCIDR(*ipd.Subnet)
+			if err != nil {
+				found.add("%s has invalid subnet %s", owner, *ipd.Subnet)
+				continue
+			}
+			for _, other := range subnets {
+				if other.net.Contains(ipNet.IP) || ipNet.Contains(other.net.IP) {
+					found.add("subnet %s of %s overlaps subnet %s of %s", ipNet, owner, other.net, other.owner)
+				}
+			}
+			subnets = append(subnets, subnet{owner: owner, net: ipNet})
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"github.com/openconfig/ygot/ygot"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestValidateConfig(t *testing.T) {
+	device := BuildSampleDevice()
+	assert.Nil(t, ValidateConfig(device))
+
+	enterprise := device.Enterprises.Enterprise["sample-ent"]
+	site := enterprise.Site["sample-site"]
+
+	// A second device-group with the same device, and so the same IMSI
+	dgCopy, err := ygot.DeepCopy(site.DeviceGroup["sample-dg"])
+	assert.Nil(t, err)
+	dupDg := dgCopy.(*DeviceGroup)
+	dupDg.DeviceGroupId = aStr("dup-dg")
+	site.DeviceGroup["dup-dg"] = dupDg
+
+	// An ip-domain inside the sample ip-domain's 1.2.3.0/24
+	site.IpDomain["overlap-ipd"] = &IpDomain{IpDomainId: aStr("overlap-ipd"), Subnet: aStr("1.2.3.128/25")}
+
+	// A slice that uses a device-group that does not exist
+	site.Slice["sample-slice"].DeviceGroup["missing-dg"] = &SliceDeviceGroup{DeviceGroup: aStr("missing-dg"), Enable: aBool(true)}
+
+	// The ip-domain that both device-groups use is disabled
+	site.IpDomain["sample-ipd"].AdminStatus = aStr("DISABLE")
+
+	// Disabling the link to a connectivity service only stops pushing to it
+	enterprise.ConnectivityService["sample-cs"].Enabled = aBool(false)
+
+	err = ValidateConfig(device)
+	validationErr, okay := err.(*ValidationError)
+	assert.True(t, okay)
+	assert.Equal(t, []string{
+		"IMSI 123456789000001 is in both device-group sample-ent/sample-site/dup-dg and device-group sample-ent/sample-site/sample-dg",
+		"device-group sample-ent/sample-site/dup-dg refers to ip-domain sample-ent/sample-site/sample-ipd, whose admin-status is DISABLE",
+		"device-group sample-ent/sample-site/sample-dg refers to ip-domain sample-ent/sample-site/sample-ipd, whose admin-status is DISABLE",
+		"slice sample-ent/sample-site/sample-slice refers to missing device-group sample-ent/sample-site/missing-dg",
+		"subnet 1.2.3.0/24 of ip-domain sample-ent/sample-site/sample-ipd overlaps subnet 1.2.3.128/25 of ip-domain sample-ent/sample-site/overlap-ipd",
+	}, validationErr.Violations)
+	assert.Contains(t, err.Error(), "5 violations: ")
+}
@@ -23,4 +23,17 @@ type SynchronizerInterface interface { //nolint
 type PusherInterface interface {
 	PushUpdate(endpoint string, data []byte) error
 	PushDelete(endpoint string) error
+	UpdateMethod(endpoint string) string
+}
+
+// FetcherInterface is an interface to a fetcher, which reads json back from underlying services.
+//go:generate mockgen -destination=../test/mocks/mock_fetcher.go -package=mocks github.com/onosproject/sdcore-adapter/pkg/synchronizer FetcherInterface
+type FetcherInterface interface {
+	Fetch(endpoint string) ([]byte, error)
+}
+
+// ResolverInterface is an interface to a resolver, which looks up the addresses of host names.
+//go:generate mockgen -destination=../test/mocks/mock_resolver.go -package=mocks github.com/onosproject/sdcore-adapter/pkg/synchronizer ResolverInterface
+type ResolverInterface interface {
+	LookupHost(host string) ([]string, error)
 }
@@ -41,4 +41,45 @@ var (
 	},
 		[]string{"cs", "kind"},
 	)
+
+	// KpiRetryTotal is the total number of retries of failed pushes
+	KpiRetryTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "synchronization_retry_total",
+		Help: "The total number of retries of failed pushes",
+	},
+		[]string{"cs", "kind"},
+	)
+
+	// KpiRetryPending is the number of failed pushes waiting to be retried
+	KpiRetryPending = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "synchronization_retry_pending",
+		Help: "The number of failed pushes waiting to be retried",
+	},
+		[]string{"cs", "kind"},
+	)
+
+	// KpiOrphansTotal is the total number of orphaned objects found on the core by reconcile
+	KpiOrphansTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "synchronization_orphans_total",
+		Help: "The total number of orphaned objects found by reconcile",
+	},
+		[]string{"cs", "kind"},
+	)
+
+	// KpiDrift is the number of objects whose state on the core differed from the model
+	// at the last drift check
+	KpiDrift = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "synchronization_drift",
+		Help: "The number of objects on the core that have drifted from the model",
+	},
+		[]string{"cs", "kind"},
+	)
+
+	// KpiUpfReachable is 1 for each UPF config endpoint that answered its last request
+	KpiUpfReachable = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "synchronization_upf_reachable",
+		Help: "Whether a UPF config endpoint answered its last request",
+	},
+		[]string{"endpoint"},
+	)
 )
@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// OutputSink implements a record of the JSON documents sent to the core and UPF.
+
+package synchronizer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// OutputOperationUpdate is the operation recorded for updates
+	OutputOperationUpdate = "update"
+
+	// OutputOperationDelete is the operation recorded for deletes
+	OutputOperationDelete = "delete"
+)
+
+// OutputSink writes every document that the synchronizer pushes, or would push if posting
+// were enabled. If the path names an existing regular file, or ends in ".json" or ".jsonl",
+// then the sink is in file mode and appends one outputRecord per line to the file.
+// Otherwise the sink is in directory mode, and the path is a directory holding one file
+// per object, named <kind>-<id>.json. When an object is deleted, its file is replaced by
+// a tombstone named <kind>-<id>.deleted.json.
+type OutputSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+// outputRecord is a single record in a file mode sink, or a tombstone in a directory mode sink
+type outputRecord struct {
+	Time      time.Time       `json:"time"`
+	Operation string          `json:"operation"`
+	Kind      string          `json:"kind"`
+	ID        string          `json:"id"`
+	Endpoint  string          `json:"endpoint"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// NewOutputSink creates an OutputSink that writes to path
+func NewOutputSink(path string) *OutputSink {
+	return &OutputSink{path: path}
+}
+
+func (o *OutputSink) isFileMode() bool {
+	if strings.HasSuffix(o.path, ".json") || strings.HasSuffix(o.path, ".jsonl") {
+		return true
+	}
+	info, err := os.Stat(o.path)
+	return (err == nil) && info.Mode().IsRegular()
+}
+
+// objectFileName returns the directory-mode file name for an object
+func (o *OutputSink) objectFileName(kind string, id string, suffix string) string {
+	// IDs are YANG identifiers, but be defensive about path separators
+	safeID := strings.ReplaceAll(id, string(os.PathSeparator), "_")
+	return filepath.Join(o.path, fmt.Sprintf("%s-%s%s", kind, safeID, suffix))
+}
+
+// appendRecord appends a record to a file mode sink. Caller must hold o.mu.
+func (o *OutputSink) appendRecord(record *outputRecord) error {
+	if record.Data != nil {
+		compact := &bytes.Buffer{}
+		if err := json.Compact(compact, record.Data); err != nil {
+			return err
+		}
+		record.Data = compact.Bytes()
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(o.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	closeErr := f.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// WriteUpdate records an update of (kind, id)
+func (o *OutputSink) WriteUpdate(kind string, id string, endpoint string, data []byte) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.isFileMode() {
+		return o.appendRecord(&outputRecord{
+			Time:      time.Now(),
+			Operation: OutputOperationUpdate,
+			Kind:      kind,
+			ID:        id,
+			Endpoint:  endpoint,
+			Data:      data,
+		})
+	}
+
+	err := os.MkdirAll(o.path, 0755)
+	if err != nil {
+		return err
+	}
+
+	// The object exists again, so it is no longer deleted
+	err = os.Remove(o.objectFileName(kind, id, ".deleted.json"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return writeFileAtomic(o.objectFileName(kind, id, ".json"), data)
+}
+
+// WriteDelete records a delete of (kind, id)
+func (o *OutputSink) WriteDelete(kind string, id string, endpoint string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	record := &outputRecord{
+		Time:      time.Now(),
+		Operation: OutputOperationDelete,
+		Kind:      kind,
+		ID:        id,
+		Endpoint:  endpoint,
+	}
+
+	if o.isFileMode() {
+		return o.appendRecord(record)
+	}
+
+	err := os.MkdirAll(o.path, 0755)
+	if err != nil {
+		return err
+	}
+
+	tombstone, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = writeFileAtomic(o.objectFileName(kind, id, ".deleted.json"), tombstone)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(o.objectFileName(kind, id, ".json"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutputSinkDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "synchronizer-output")
+	assert.Nil(t, err)
+	defer func() {
+		assert.Nil(t, os.RemoveAll(dir))
+	}()
+
+	sink := NewOutputSink(dir)
+
+	err = sink.WriteUpdate(CacheModelSlice, "my-slice", "http://5gcore/v1/network-slice/my-slice", []byte(`{"a": 1}`))
+	assert.Nil(t, err)
+	content, err := ioutil.ReadFile(filepath.Join(dir, "slice-my-slice.json"))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"a": 1}`, string(content))
+
+	err = sink.WriteDelete(CacheModelSlice, "my-slice", "http://5gcore/v1/network-slice/my-slice")
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(dir, "slice-my-slice.json"))
+	assert.True(t, os.IsNotExist(err))
+	content, err = ioutil.ReadFile(filepath.Join(dir, "slice-my-slice.deleted.json"))
+	assert.Nil(t, err)
+	record := outputRecord{}
+	assert.Nil(t, json.Unmarshal(content, &record))
+	assert.Equal(t, OutputOperationDelete, record.Operation)
+	assert.Equal(t, "my-slice", record.ID)
+	assert.Equal(t, "http://5gcore/v1/network-slice/my-slice", record.Endpoint)
+
+	// Recreating the object removes the tombstone
+	err = sink.WriteUpdate(CacheModelSlice, "my-slice", "http://5gcore/v1/network-slice/my-slice", []byte(`{"a": 2}`))
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(dir, "slice-my-slice.deleted.json"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+// With posting disabled, the synchronizer renders to the output sink and pushes nothing
+func TestOutputSinkRenderOnly(t *testing.T) {
+	f, err := ioutil.TempFile("", "synchronizer-output")
+	assert.Nil(t, err)
+	tempFileName := f.Name()
+	assert.Nil(t, f.Close())
+	defer func() {
+		assert.Nil(t, os.Remove(tempFileName))
+	}()
+
+	jsonDataDg, err := ioutil.ReadFile("./testdata/sample-dg.json")
+	assert.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithOutputFileName(tempFileName), WithPostEnable(false))
+
+	device := BuildSampleDevice()
+	pushErrors, err := s.SynchronizeDevice(device)
+	assert.Equal(t, 0, pushErrors)
+	assert.Nil(t, err)
+
+	// Nothing was pushed, so nothing is cached
+	assert.Empty(t, s.cache)
+
+	err = s.HandleDelete(device, BuildRootPath("sample-ent", "sample-site", "dg-id", "device-group", "sample-dg"))
+	assert.Nil(t, err)
+
+	f, err = os.Open(tempFileName)
+	assert.Nil(t, err)
+	defer f.Close()
+
+	records := []outputRecord{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		record := outputRecord{}
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+
+	assert.Equal(t, 4, len(records))
+	assert.Equal(t, CacheModelDeviceGroup, records[0].Kind)
+	assert.Equal(t, "sample-dg", records[0].ID)
+	assert.Equal(t, "http://5gcore/v1/device-group/sample-dg", records[0].Endpoint)
+	require.JSONEq(t, string(jsonDataDg), string(records[0].Data))
+	assert.Equal(t, CacheModelSlice, records[1].Kind)
+	assert.Equal(t, CacheModelSliceUpf, records[2].Kind)
+	assert.Equal(t, OutputOperationDelete, records[3].Operation)
+	assert.Equal(t, "sample-dg", records[3].ID)
+}
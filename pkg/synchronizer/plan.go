@@ -0,0 +1,206 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Plan implements dry-run mode, where pushes are recorded instead of sent.
+
+package synchronizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+const (
+	// PlanChangeCreate is an update to an object that is not in the cache
+	PlanChangeCreate = "create"
+
+	// PlanChangeModify is an update to an object that is in the cache
+	PlanChangeModify = "modify"
+
+	// PlanChangeDelete is a delete of an object
+	PlanChangeDelete = "delete"
+)
+
+// PlanDiff is a single difference between the cached and intended contents of an object
+type PlanDiff struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// PlanEntry is a single push that the synchronizer would have made
+type PlanEntry struct {
+	Kind     string          `json:"kind"`
+	ID       string          `json:"id"`
+	Change   string          `json:"change"`
+	Method   string          `json:"method"`
+	Endpoint string          `json:"endpoint"`
+	Body     json.RawMessage `json:"body,omitempty"`
+	Diff     []PlanDiff      `json:"diff,omitempty"`
+}
+
+// Plan is the list of pushes computed by a synchronization in dry-run mode
+type Plan struct {
+	Time    time.Time   `json:"time"`
+	Entries []PlanEntry `json:"entries"`
+}
+
+// WithDryRun sets the dryRun option. In dry-run mode, nothing is pushed; the pushes are
+// recorded in a Plan instead.
+func WithDryRun(dryRun bool) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.dryRun = dryRun
+	}
+}
+
+// GetPlan returns the plan computed by the most recent synchronization, or nil if
+// there is none.
+func (s *Synchronizer) GetPlan() *Plan {
+	s.planMutex.Lock()
+	defer s.planMutex.Unlock()
+
+	if s.plan == nil {
+		return nil
+	}
+
+	planCopy := *s.plan
+	planCopy.Entries = append([]PlanEntry{}, s.plan.Entries...)
+	return &planCopy
+}
+
+// planBegin starts building a new plan. The previous plan remains visible until
+// planEnd is called.
+func (s *Synchronizer) planBegin() {
+	s.planMutex.Lock()
+	defer s.planMutex.Unlock()
+
+	s.pendingPlan = &Plan{Time: time.Now(), Entries: []PlanEntry{}}
+}
+
+// planEnd makes the plan that is being built the current plan
+func (s *Synchronizer) planEnd() {
+	s.planMutex.Lock()
+	defer s.planMutex.Unlock()
+
+	if s.pendingPlan != nil {
+		s.plan = s.pendingPlan
+		s.pendingPlan = nil
+	}
+}
+
+// planAdd adds an entry to the plan that is being built. Entries that arrive outside of
+// a synchronization, such as deletes, are added to the current plan.
+func (s *Synchronizer) planAdd(entry PlanEntry) {
+	s.planMutex.Lock()
+	defer s.planMutex.Unlock()
+
+	log.Infof("Dry-run: %s %s %s %s", entry.Method, entry.Kind, entry.ID, entry.Endpoint)
+
+	if s.pendingPlan != nil {
+		s.pendingPlan.Entries = append(s.pendingPlan.Entries, entry)
+		return
+	}
+	if s.plan == nil {
+		s.plan = &Plan{Time: time.Now(), Entries: []PlanEntry{}}
+	}
+	s.plan.Entries = append(s.plan.Entries, entry)
+}
+
+// planUpdate adds an update of (kind, id) to the plan, along with the differences
+// between data and what is in the cache.
+func (s *Synchronizer) planUpdate(kind string, id string, endpoint string, data []byte) {
+	entry := PlanEntry{
+		Kind:     kind,
+		ID:       id,
+		Change:   PlanChangeCreate,
+		Method:   s.pusher.UpdateMethod(endpoint),
+		Endpoint: endpoint,
+		Body:     json.RawMessage(data),
+	}
+
+	s.cacheMutex.Lock()
+	cached, okay := s.cache[cacheKey(kind, id)]
+	s.cacheMutex.Unlock()
+
+	if okay {
+		entry.Change = PlanChangeModify
+		diff, err := jsonDiff(cached, data)
+		if err != nil {
+			log.Warnf("Dry-run: failed to diff %s %s: %v", kind, id, err)
+		}
+		entry.Diff = diff
+	}
+
+	s.planAdd(entry)
+}
+
+// planDelete adds a delete of (kind, id) to the plan
+func (s *Synchronizer) planDelete(kind string, id string, endpoint string) {
+	s.planAdd(PlanEntry{
+		Kind:     kind,
+		ID:       id,
+		Change:   PlanChangeDelete,
+		Method:   "DELETE",
+		Endpoint: endpoint,
+	})
+}
+
+// flattenJSON turns a decoded JSON document into a map of path to leaf value
+func flattenJSON(prefix string, v interface{}, out map[string]interface{}) {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		for k, child := range vv {
+			flattenJSON(prefix+"/"+k, child, out)
+		}
+	case []interface{}:
+		for i, child := range vv {
+			flattenJSON(fmt.Sprintf("%s[%d]", prefix, i), child, out)
+		}
+	default:
+		out[prefix] = v
+	}
+}
+
+// jsonDiff returns the leaves that differ between two JSON documents, sorted by path
+func jsonDiff(oldData []byte, newData []byte) ([]PlanDiff, error) {
+	var oldDoc, newDoc interface{}
+	if err := json.Unmarshal(oldData, &oldDoc); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(newData, &newDoc); err != nil {
+		return nil, err
+	}
+
+	oldLeaves := map[string]interface{}{}
+	newLeaves := map[string]interface{}{}
+	flattenJSON("", oldDoc, oldLeaves)
+	flattenJSON("", newDoc, newLeaves)
+
+	paths := map[string]bool{}
+	for k := range oldLeaves {
+		paths[k] = true
+	}
+	for k := range newLeaves {
+		paths[k] = true
+	}
+
+	diff := []PlanDiff{}
+	for path := range paths {
+		oldVal, oldOkay := oldLeaves[path]
+		newVal, newOkay := newLeaves[path]
+		if oldOkay && newOkay && reflect.DeepEqual(oldVal, newVal) {
+			continue
+		}
+		diff = append(diff, PlanDiff{Path: path, Old: oldVal, New: newVal})
+	}
+
+	sort.Slice(diff, func(i, j int) bool {
+		return diff[i].Path < diff[j].Path
+	})
+
+	return diff, nil
+}
@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestJSONDiff(t *testing.T) {
+	diff, err := jsonDiff([]byte(`{"a": 1, "b": {"c": "x", "d": [1, 2]}}`),
+		[]byte(`{"a": 1, "b": {"c": "y", "d": [1]}, "e": true}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []PlanDiff{
+		{Path: "/b/c", Old: "x", New: "y"},
+		{Path: "/b/d[1]", Old: float64(2)},
+		{Path: "/e", New: true},
+	}, diff)
+
+	diff, err = jsonDiff([]byte(`{"a": 1}`), []byte(`{"a": 1}`))
+	assert.Nil(t, err)
+	assert.Empty(t, diff)
+}
+
+// In dry-run mode, nothing is pushed and the cache is left alone
+func TestSynchronizeDeviceDryRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithDryRun(true))
+	mockPusher.EXPECT().UpdateMethod(gomock.Any()).Return("POST").AnyTimes()
+
+	assert.Nil(t, s.GetPlan())
+
+	// The core already has the device-group, but with a different IP domain
+	s.cache[cacheKey(CacheModelDeviceGroup, "sample-dg")] = []byte(`{"ip-domain-name":"old-ipd"}`)
+
+	device := BuildSampleDevice()
+	pushErrors, err := s.SynchronizeDevice(device)
+	assert.Equal(t, 0, pushErrors)
+	assert.Nil(t, err)
+
+	plan := s.GetPlan()
+	assert.NotNil(t, plan)
+	assert.Equal(t, 3, len(plan.Entries))
+
+	assert.Equal(t, CacheModelDeviceGroup, plan.Entries[0].Kind)
+	assert.Equal(t, "sample-dg", plan.Entries[0].ID)
+	assert.Equal(t, PlanChangeModify, plan.Entries[0].Change)
+	assert.Equal(t, "http://5gcore/v1/device-group/sample-dg", plan.Entries[0].Endpoint)
+	assert.Contains(t, plan.Entries[0].Diff, PlanDiff{Path: "/ip-domain-name", Old: "old-ipd", New: "sample-ipd"})
+
+	assert.Equal(t, CacheModelSlice, plan.Entries[1].Kind)
+	assert.Equal(t, PlanChangeCreate, plan.Entries[1].Change)
+	assert.Equal(t, "POST", plan.Entries[1].Method)
+	assert.Equal(t, "http://5gcore/v1/network-slice/sample-slice", plan.Entries[1].Endpoint)
+	assert.Empty(t, plan.Entries[1].Diff)
+
+	assert.Equal(t, CacheModelSliceUpf, plan.Entries[2].Kind)
+	assert.Equal(t, "http://upf/v1/config/network-slices", plan.Entries[2].Endpoint)
+
+	// The cache must not have been updated
+	assert.Equal(t, 1, len(s.cache))
+
+	// A second pass produces the same plan
+	_, err = s.SynchronizeDevice(device)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(s.GetPlan().Entries))
+
+	// Deletes are added to the current plan
+	err = s.HandleDelete(device, BuildRootPath("sample-ent", "sample-site", "dg-id", "device-group", "sample-dg"))
+	assert.Nil(t, err)
+	plan = s.GetPlan()
+	assert.Equal(t, 4, len(plan.Entries))
+	assert.Equal(t, PlanChangeDelete, plan.Entries[3].Change)
+	assert.Equal(t, "DELETE", plan.Entries[3].Method)
+	assert.Equal(t, 1, len(s.cache))
+}
@@ -8,12 +8,44 @@ package synchronizer
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 )
 
-// PushError is an error class that is returned for failed POSTs and DELETEs. It
+const (
+	// DefaultMaxIdleConnsPerHost is the default number of idle connections kept open to each endpoint
+	DefaultMaxIdleConnsPerHost = 4
+
+	// EndpointTypeNetworkSlice is a slice on the core, <core>/v1/network-slice/<id>
+	EndpointTypeNetworkSlice = "network-slice"
+
+	// EndpointTypeDeviceGroup is a device-group on the core, <core>/v1/device-group/<id>
+	EndpointTypeDeviceGroup = "device-group"
+
+	// EndpointTypeUpfNetworkSlices is the list of slices on the UPF, <upf>/v1/config/network-slices
+	EndpointTypeUpfNetworkSlices = "upf-network-slices"
+
+	// EndpointTypeOther is any endpoint not listed above
+	EndpointTypeOther = "other"
+)
+
+// DefaultUpdateMethods are the HTTP methods used for updates to each type of endpoint. The
+// core treats PUT as an idempotent update. The UPF takes the slice in a POST to a list.
+var DefaultUpdateMethods = map[string]string{
+	EndpointTypeNetworkSlice:     http.MethodPut,
+	EndpointTypeDeviceGroup:      http.MethodPut,
+	EndpointTypeUpfNetworkSlices: http.MethodPost,
+	EndpointTypeOther:            http.MethodPost,
+}
+
+// PushError is an error class that is returned for failed PUTs, POSTs and DELETEs. It
 // makes it easier to detect a nonfatal error, such as a 404.
 type PushError struct {
 	Endpoint   string
@@ -26,67 +58,284 @@ func (e *PushError) Error() string {
 	return fmt.Sprintf("Push Error op=%s endpoint=%s code=%d status=%s", e.Operation, e.Endpoint, e.StatusCode, e.Status)
 }
 
-// RESTPusher implements a pusher that pushes to a rest endpoint.
+// RESTPusher implements a pusher that pushes to a rest endpoint. A single http.Client is
+// shared by all pushes, so that connections to the endpoints are reused.
 type RESTPusher struct {
+	client              *http.Client
+	timeout             time.Duration
+	maxIdleConnsPerHost int
+	caFile              string
+	certFile            string
+	keyFile             string
+	bearerToken         string
+	username            string
+	password            string
+	updateMethods       map[string]string
+
+	// Endpoints that rejected PUT, and get POST instead
+	postOnly      map[string]bool
+	postOnlyMutex sync.Mutex
 }
 
-// PushUpdate pushes an update to the REST endpoint.
-func (p *RESTPusher) PushUpdate(endpoint string, data []byte) error {
-	client := &http.Client{
-		Timeout: time.Second * 10,
+// RESTPusherOption is for options passed when creating a new RESTPusher
+type RESTPusherOption func(p *RESTPusher)
+
+// WithPushTimeout sets the timeout for each push
+func WithPushTimeout(timeout time.Duration) RESTPusherOption {
+	return func(p *RESTPusher) {
+		p.timeout = timeout
+	}
+}
+
+// WithMaxIdleConnsPerHost sets the number of idle connections kept open to each endpoint
+func WithMaxIdleConnsPerHost(maxIdleConnsPerHost int) RESTPusherOption {
+	return func(p *RESTPusher) {
+		p.maxIdleConnsPerHost = maxIdleConnsPerHost
+	}
+}
+
+// WithTLS sets the CA used to verify the endpoints, and the client certificate and key
+// presented to them. Any of the files may be empty. The client certificate and key must
+// be given together.
+func WithTLS(caFile string, certFile string, keyFile string) RESTPusherOption {
+	return func(p *RESTPusher) {
+		p.caFile = caFile
+		p.certFile = certFile
+		p.keyFile = keyFile
 	}
+}
 
-	log.Infof("Push Update endpoint=%s data=%s", endpoint, string(data))
+// WithBearerToken sets a token that is sent in the Authorization header of every push
+func WithBearerToken(token string) RESTPusherOption {
+	return func(p *RESTPusher) {
+		p.bearerToken = token
+	}
+}
 
-	resp, err := client.Post(
-		endpoint,
-		"application/json",
-		bytes.NewBuffer(data))
+// WithBasicAuth sets a username and password that are sent with every push
+func WithBasicAuth(username string, password string) RESTPusherOption {
+	return func(p *RESTPusher) {
+		p.username = username
+		p.password = password
+	}
+}
 
-	/* In the future, PUT will be the correct operation
-	resp, err := httpPut(client, endpoint, "application/json", data)
-	*/
+// WithUpdateMethod sets the HTTP method, PUT or POST, used for updates to a type of endpoint
+func WithUpdateMethod(endpointType string, method string) RESTPusherOption {
+	return func(p *RESTPusher) {
+		p.updateMethods[endpointType] = strings.ToUpper(method)
+	}
+}
 
+// EndpointType returns the type of an endpoint, from its path
+func EndpointType(endpoint string) string {
+	u, err := url.Parse(endpoint)
 	if err != nil {
-		return err
+		return EndpointTypeOther
+	}
+	switch {
+	case strings.HasSuffix(u.Path, "/v1/config/network-slices"):
+		return EndpointTypeUpfNetworkSlices
+	case strings.Contains(u.Path, "/v1/network-slice/"):
+		return EndpointTypeNetworkSlice
+	case strings.Contains(u.Path, "/v1/device-group/"):
+		return EndpointTypeDeviceGroup
+	}
+	return EndpointTypeOther
+}
+
+// capabilityKey identifies the server and type of an endpoint. What one slice on a core
+// supports, every slice on that core supports.
+func capabilityKey(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return endpoint
+	}
+	return fmt.Sprintf("%s://%s/%s", u.Scheme, u.Host, EndpointType(endpoint))
+}
+
+// NewRESTPusher creates a new RESTPusher
+func NewRESTPusher(opts ...RESTPusherOption) (*RESTPusher, error) {
+	p := &RESTPusher{
+		timeout:             DefaultPostTimeout,
+		maxIdleConnsPerHost: DefaultMaxIdleConnsPerHost,
+		updateMethods:       map[string]string{},
+		postOnly:            map[string]bool{},
+	}
+	for endpointType, method := range DefaultUpdateMethods {
+		p.updateMethods[endpointType] = method
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	for endpointType, method := range p.updateMethods {
+		if (method != http.MethodPut) && (method != http.MethodPost) {
+			return nil, fmt.Errorf("Update method for %s must be PUT or POST, not %s", endpointType, method)
+		}
+	}
+
+	if (p.bearerToken != "") && (p.username != "") {
+		return nil, fmt.Errorf("Bearer token and basic auth may not both be used")
+	}
+
+	if (p.certFile == "") != (p.keyFile == "") {
+		return nil, fmt.Errorf("Client certificate and key must be specified together")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = p.maxIdleConnsPerHost
+
+	if (p.caFile != "") || (p.certFile != "") {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+		if p.caFile != "" {
+			caPem, err := ioutil.ReadFile(p.caFile)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to read CA %s: %v", p.caFile, err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(caPem) {
+				return nil, fmt.Errorf("No certificates found in CA %s", p.caFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+
+		if p.certFile != "" {
+			cert, err := tls.LoadX509KeyPair(p.certFile, p.keyFile)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to load client certificate %s: %v", p.certFile, err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	p.client = &http.Client{
+		Timeout:   p.timeout,
+		Transport: transport,
+	}
+
+	return p, nil
+}
+
+// defaultClient is used by a RESTPusher that was not created by NewRESTPusher
+var defaultClient = &http.Client{Timeout: DefaultPostTimeout}
+
+// httpClient returns the client to push with
+func (p *RESTPusher) httpClient() *http.Client {
+	if p.client == nil {
+		return defaultClient
+	}
+	return p.client
+}
+
+// do sends a request, adding authentication, and turns an unsuccessful status into a PushError
+func (p *RESTPusher) do(req *http.Request, endpoint string) error {
+	_, err := p.doWithBody(req, endpoint)
+	return err
+}
+
+// doWithBody is do, returning the body of the response
+func (p *RESTPusher) doWithBody(req *http.Request, endpoint string) ([]byte, error) {
+	if p.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+p.bearerToken)
+	} else if p.username != "" {
+		req.SetBasicAuth(p.username, p.password)
+	}
+
+	resp, err := p.httpClient().Do(req)
+	if err != nil {
+		return nil, err
 	}
 
 	defer resp.Body.Close()
 
-	log.Infof("Put returned status %s", resp.Status)
+	log.Infof("%s returned status %s", req.Method, resp.Status)
 
 	if (resp.StatusCode < 200) || (resp.StatusCode >= 300) {
-		return &PushError{Operation: "POST", Endpoint: endpoint, StatusCode: resp.StatusCode, Status: resp.Status}
+		return nil, &PushError{Operation: req.Method, Endpoint: endpoint, StatusCode: resp.StatusCode, Status: resp.Status}
 	}
 
-	return nil
+	return ioutil.ReadAll(resp.Body)
 }
 
-// PushDelete pushes a delete to the REST endpoint
-func (p *RESTPusher) PushDelete(endpoint string) error {
-	client := &http.Client{
-		Timeout: time.Second * 10,
+// UpdateMethod returns the HTTP method that will be used for an update to endpoint
+func (p *RESTPusher) UpdateMethod(endpoint string) string {
+	method, okay := p.updateMethods[EndpointType(endpoint)]
+	if !okay {
+		method = http.MethodPost
 	}
+	if method == http.MethodPut {
+		p.postOnlyMutex.Lock()
+		defer p.postOnlyMutex.Unlock()
+		if p.postOnly[capabilityKey(endpoint)] {
+			method = http.MethodPost
+		}
+	}
+	return method
+}
 
-	log.Infof("Push Delete endpoint=%s", endpoint)
-
-	req, err := http.NewRequest("DELETE", endpoint, nil)
+// pushUpdateMethod pushes an update using the given HTTP method
+func (p *RESTPusher) pushUpdateMethod(method string, endpoint string, data []byte) error {
+	req, err := http.NewRequest(method, endpoint, bytes.NewBuffer(data))
 	if err != nil {
 		return err
 	}
-	resp, err := client.Do(req)
+	req.Header.Set("Content-Type", "application/json")
 
+	return p.do(req, endpoint)
+}
+
+// PushUpdate pushes an update to the REST endpoint. If a PUT is rejected because the
+// endpoint does not support it, the update is sent again as a POST, and the endpoint is
+// remembered as only supporting POST.
+func (p *RESTPusher) PushUpdate(endpoint string, data []byte) error {
+	method := p.UpdateMethod(endpoint)
+
+	log.Infof("Push Update method=%s endpoint=%s data=%s", method, endpoint, string(data))
+
+	err := p.pushUpdateMethod(method, endpoint, data)
+	if method != http.MethodPut {
+		return err
+	}
+
+	pushError, okay := err.(*PushError)
+	if !okay || ((pushError.StatusCode != http.StatusMethodNotAllowed) && (pushError.StatusCode != http.StatusNotImplemented)) {
+		return err
+	}
+
+	log.Infof("Endpoint %s does not support PUT, falling back to POST", endpoint)
+	p.postOnlyMutex.Lock()
+	p.postOnly[capabilityKey(endpoint)] = true
+	p.postOnlyMutex.Unlock()
+
+	return p.pushUpdateMethod(http.MethodPost, endpoint, data)
+}
+
+// PushDelete pushes a delete to the REST endpoint
+func (p *RESTPusher) PushDelete(endpoint string) error {
+	log.Infof("Push Delete endpoint=%s", endpoint)
+
+	req, err := http.NewRequest("DELETE", endpoint, nil)
 	if err != nil {
 		return err
 	}
 
-	defer resp.Body.Close()
+	return p.do(req, endpoint)
+}
 
-	log.Infof("Delete returned status %s", resp.Status)
+// Fetch reads a document back from the REST endpoint
+func (p *RESTPusher) Fetch(endpoint string) ([]byte, error) {
+	log.Debugf("Fetch endpoint=%s", endpoint)
 
-	if (resp.StatusCode < 200) || (resp.StatusCode >= 300) {
-		return &PushError{Operation: "DELETE", Endpoint: endpoint, StatusCode: resp.StatusCode, Status: resp.Status}
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
 	}
+	req.Header.Set("Accept", "application/json")
 
-	return nil
+	return p.doWithBody(req, endpoint)
 }
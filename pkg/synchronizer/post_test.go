@@ -0,0 +1,188 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRESTPusherUpdate(t *testing.T) {
+	var gotMethod, gotContentType, gotAuth, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotContentType = r.Header.Get("Content-Type")
+		gotAuth = r.Header.Get("Authorization")
+		body, _ := ioutil.ReadAll(r.Body)
+		gotBody = string(body)
+	}))
+	defer server.Close()
+
+	p, err := NewRESTPusher(WithBearerToken("secret"))
+	assert.Nil(t, err)
+
+	err = p.PushUpdate(server.URL+"/v1/network-slice/my-slice", []byte(`{"a": 1}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "PUT", gotMethod)
+	assert.Equal(t, "application/json", gotContentType)
+	assert.Equal(t, "Bearer secret", gotAuth)
+	assert.Equal(t, `{"a": 1}`, gotBody)
+
+	p, err = NewRESTPusher(WithBasicAuth("user", "pw"))
+	assert.Nil(t, err)
+	err = p.PushDelete(server.URL + "/v1/network-slice/my-slice")
+	assert.Nil(t, err)
+	assert.Equal(t, "DELETE", gotMethod)
+	assert.Equal(t, "Basic dXNlcjpwdw==", gotAuth)
+}
+
+func TestRESTPusherError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such thing", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	p, err := NewRESTPusher()
+	assert.Nil(t, err)
+
+	endpoint := server.URL + "/v1/device-group/my-dg"
+	err = p.PushDelete(endpoint)
+	pushError, okay := err.(*PushError)
+	assert.True(t, okay)
+	assert.Equal(t, 404, pushError.StatusCode)
+	assert.Equal(t, "DELETE", pushError.Operation)
+	assert.Equal(t, endpoint, pushError.Endpoint)
+}
+
+func TestRESTPusherTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	p, err := NewRESTPusher(WithPushTimeout(50 * time.Millisecond))
+	assert.Nil(t, err)
+
+	err = p.PushUpdate(server.URL, []byte("{}"))
+	assert.NotNil(t, err)
+	_, okay := err.(*PushError)
+	assert.False(t, okay)
+
+	// The synchronizer's default pusher honors the post timeout
+	s := NewSynchronizer(WithPostTimeout(7 * time.Second))
+	assert.Equal(t, 7*time.Second, s.pusher.(*RESTPusher).timeout)
+}
+
+func TestRESTPusherTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	caFile, err := ioutil.TempFile("", "pusher-ca")
+	assert.Nil(t, err)
+	defer func() {
+		assert.Nil(t, os.Remove(caFile.Name()))
+	}()
+	err = pem.Encode(caFile, &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	assert.Nil(t, err)
+	assert.Nil(t, caFile.Close())
+
+	// Without the CA, the server is not trusted
+	p, err := NewRESTPusher()
+	assert.Nil(t, err)
+	assert.NotNil(t, p.PushUpdate(server.URL, []byte("{}")))
+
+	p, err = NewRESTPusher(WithTLS(caFile.Name(), "", ""))
+	assert.Nil(t, err)
+	assert.Nil(t, p.PushUpdate(server.URL, []byte("{}")))
+
+	_, err = NewRESTPusher(WithTLS("", caFile.Name(), ""))
+	assert.NotNil(t, err)
+
+	_, err = NewRESTPusher(WithBearerToken("secret"), WithBasicAuth("user", "pw"))
+	assert.NotNil(t, err)
+}
+
+func TestEndpointType(t *testing.T) {
+	assert.Equal(t, EndpointTypeNetworkSlice, EndpointType("http://5gcore/v1/network-slice/my-slice"))
+	assert.Equal(t, EndpointTypeDeviceGroup, EndpointType("http://5gcore/v1/device-group/my-dg"))
+	assert.Equal(t, EndpointTypeUpfNetworkSlices, EndpointType("http://upf/v1/config/network-slices"))
+	assert.Equal(t, EndpointTypeOther, EndpointType("http://somewhere/else"))
+}
+
+func TestRESTPusherPutFallback(t *testing.T) {
+	methods := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method+" "+r.URL.Path)
+		if r.Method == "PUT" {
+			http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		}
+	}))
+	defer server.Close()
+
+	p, err := NewRESTPusher()
+	assert.Nil(t, err)
+
+	// The first update tries PUT, then falls back to POST
+	err = p.PushUpdate(server.URL+"/v1/network-slice/slice1", []byte("{}"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"PUT /v1/network-slice/slice1", "POST /v1/network-slice/slice1"}, methods)
+
+	// The next slice on the same core goes straight to POST
+	methods = []string{}
+	assert.Equal(t, "POST", p.UpdateMethod(server.URL+"/v1/network-slice/slice2"))
+	err = p.PushUpdate(server.URL+"/v1/network-slice/slice2", []byte("{}"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"POST /v1/network-slice/slice2"}, methods)
+
+	// Device-groups on the same core are remembered separately
+	assert.Equal(t, "PUT", p.UpdateMethod(server.URL+"/v1/device-group/dg1"))
+
+	// The UPF uses POST by default, and can be configured
+	assert.Equal(t, "POST", p.UpdateMethod("http://upf/v1/config/network-slices"))
+	p, err = NewRESTPusher(WithUpdateMethod(EndpointTypeUpfNetworkSlices, "put"))
+	assert.Nil(t, err)
+	assert.Equal(t, "PUT", p.UpdateMethod("http://upf/v1/config/network-slices"))
+
+	_, err = NewRESTPusher(WithUpdateMethod(EndpointTypeDeviceGroup, "PATCH"))
+	assert.NotNil(t, err)
+}
+
+func TestRESTPusherFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Accept"))
+		if r.URL.Path != "/v1/network-slice" {
+			http.Error(w, "no such thing", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`["slice1"]`))
+	}))
+	defer server.Close()
+
+	p, err := NewRESTPusher()
+	assert.Nil(t, err)
+
+	data, err := p.Fetch(server.URL + "/v1/network-slice")
+	assert.Nil(t, err)
+	assert.Equal(t, `["slice1"]`, string(data))
+
+	_, err = p.Fetch(server.URL + "/v1/device-group")
+	pushError, okay := err.(*PushError)
+	assert.True(t, okay)
+	assert.Equal(t, 404, pushError.StatusCode)
+
+	// The synchronizer reads back using its REST pusher
+	s := NewSynchronizer()
+	assert.Equal(t, s.pusher, s.fetcher)
+}
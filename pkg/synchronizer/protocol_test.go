@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestProtoStringToProtoNumberIANA(t *testing.T) {
+	tests := []struct {
+		name   string
+		number uint8
+	}{
+		{"ICMP", 1},
+		{"icmp", 1},
+		{"GRE", 47},
+		{"IPv6-ICMP", 58},
+		{"ICMPv6", 58},
+		{"SCTP", 132},
+		{"UDPLite", 136},
+		{"0", 0},
+		{"132", 132},
+		{"253", 253},
+	}
+
+	for _, test := range tests {
+		n, err := ProtoStringToProtoNumber(test.name)
+		assert.Nil(t, err, test.name)
+		assert.Equal(t, test.number, n, test.name)
+	}
+
+	_, err := ProtoStringToProtoNumber("256")
+	assert.EqualError(t, err, "Unknown protocol 256")
+	_, err = ProtoStringToProtoNumber("ANY")
+	assert.EqualError(t, err, "Unknown protocol ANY")
+}
+
+func TestParseProtocol(t *testing.T) {
+	n, err := parseProtocol("any", true)
+	assert.Nil(t, err)
+	assert.Nil(t, n)
+
+	n, err = parseProtocol("SCTP", true)
+	assert.Nil(t, err)
+	assert.Equal(t, aUint8(132), n)
+
+	n, err = parseProtocol("ICMP", false)
+	assert.Nil(t, err)
+	assert.Equal(t, aUint8(1), n)
+
+	_, err = parseProtocol("ICMP", true)
+	assert.EqualError(t, err, "Protocol ICMP has no ports, but a port range is given")
+}
+
+func TestRenderSliceProtocols(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher))
+
+	device := BuildSampleDevice()
+	scope, err := BuildScope(device, "sample-ent", "sample-site", "sample-cs")
+	assert.Nil(t, err)
+	slice := scope.Site.Slice["sample-slice"]
+	slice.Filter = map[string]*SliceFilter{"sample-app": slice.Filter["sample-app"]}
+	ep := scope.Enterprise.Application["sample-app"].Endpoint["sample-app-ep"]
+
+	// ANY renders no protocol
+	ep.Protocol = aStr("ANY")
+	coreSlice, err := s.renderSlice(scope, slice)
+	assert.Nil(t, err)
+	assert.Nil(t, coreSlice.ApplicationFilteringRules[0].Protocol)
+	assert.Equal(t, aUint16(123), coreSlice.ApplicationFilteringRules[0].DestPortStart)
+
+	ep.Protocol = aStr("SCTP")
+	coreSlice, err = s.renderSlice(scope, slice)
+	assert.Nil(t, err)
+	assert.Equal(t, aUint8(132), coreSlice.ApplicationFilteringRules[0].Protocol)
+
+	ep.Protocol = aStr("ICMP")
+	_, err = s.renderSlice(scope, slice)
+	assert.EqualError(t, err, "Slice sample-slice Application sample-app unable to determine protocol: Protocol ICMP has no ports, but a port range is given")
+
+	ep.PortStart = nil
+	ep.PortEnd = nil
+	coreSlice, err = s.renderSlice(scope, slice)
+	assert.Nil(t, err)
+	assert.Equal(t, aUint8(1), coreSlice.ApplicationFilteringRules[0].Protocol)
+	assert.Nil(t, coreSlice.ApplicationFilteringRules[0].DestPortStart)
+}
@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Push implements the path that every update and delete takes on its way to the core or UPF.
+
+package synchronizer
+
+// pushUpdate records an update of (kind, id) in the output sink, and pushes it to the
+// endpoint of connectivity service cs if posting is enabled. In dry-run mode, the update
+// is only added to the plan. An object that is waiting to be retried is not pushed until
+// the retry is due; the retry will push this version instead, so this is not a failure.
+// Returns true if the update was pushed and is still the latest version, so that the
+// caller only caches what the core actually has.
+func (s *Synchronizer) pushUpdate(kind string, id string, cs string, endpoint string, data []byte) (bool, error) {
+	if s.dryRun {
+		s.planUpdate(kind, id, endpoint, data)
+		return false, nil
+	}
+
+	if s.outputSink != nil {
+		// Failing to write the output is not a reason to not push to the core
+		err := s.outputSink.WriteUpdate(kind, id, endpoint, data)
+		if err != nil {
+			log.Warnf("Failed to write output for %s %s: %v", kind, id, err)
+		}
+	}
+
+	if !s.postEnable {
+		log.Infof("Post is disabled, not pushing %s %s to %s", kind, id, endpoint)
+		return false, nil
+	}
+
+	if s.retryDefer(kind, id, cs, endpoint, data) {
+		log.Infof("%s %s is waiting to be retried, queued the latest version", kind, id)
+		return false, nil
+	}
+
+	err := s.pusher.PushUpdate(endpoint, data)
+	s.statusPushResult(kind, id, cs, endpoint, data, err)
+	s.upfPushResult(kind, endpoint, err)
+	return s.retryFinish(kind, id, cs, endpoint, data, err), err
+}
+
+// pushDelete records a delete of (kind, id) in the output sink, and pushes it to the
+// endpoint of connectivity service cs if posting is enabled. In dry-run mode, the delete
+// is only added to the plan.
+func (s *Synchronizer) pushDelete(kind string, id string, cs string, endpoint string) error {
+	if s.dryRun {
+		s.planDelete(kind, id, endpoint)
+		return nil
+	}
+
+	if s.outputSink != nil {
+		err := s.outputSink.WriteDelete(kind, id, endpoint)
+		if err != nil {
+			log.Warnf("Failed to write output for delete of %s %s: %v", kind, id, err)
+		}
+	}
+
+	if !s.postEnable {
+		log.Infof("Post is disabled, not pushing delete of %s %s to %s", kind, id, endpoint)
+		return nil
+	}
+
+	// Whatever happens to the delete, a pending retry of an update is obsolete
+	s.retryRemove(kind, id, cs)
+
+	err := s.pusher.PushDelete(endpoint)
+	if err != nil {
+		pushError, ok := err.(*PushError)
+		if !ok || pushError.StatusCode != 404 {
+			s.statusPushResult(kind, id, cs, endpoint, nil, err)
+			return err
+		}
+	}
+	s.statusDelete(kind, id, cs)
+	return err
+}
@@ -0,0 +1,242 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Reconcile implements garbage collection of objects that remain on the core after they
+// have been removed from the model.
+
+package synchronizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/openconfig/ygot/ygot"
+)
+
+// Orphan is an object that exists on the core but not in the model
+type Orphan struct {
+	Kind                string `json:"kind"`
+	ID                  string `json:"id"`
+	ConnectivityService string `json:"connectivity-service"`
+	Endpoint            string `json:"endpoint"`
+	Deleted             bool   `json:"deleted"`
+	Error               string `json:"error,omitempty"`
+}
+
+// ReconcileReport is the result of a reconciliation pass
+type ReconcileReport struct {
+	Time     time.Time `json:"time"`
+	SafeMode bool      `json:"safe-mode"`
+	Orphans  []Orphan  `json:"orphans"`
+	Errors   []string  `json:"errors,omitempty"`
+}
+
+// reconcileKind describes how to list one kind of object on the core
+type reconcileKind struct {
+	kind     string
+	listPath string
+}
+
+var reconcileKinds = []reconcileKind{
+	{kind: CacheModelDeviceGroup, listPath: "/v1/device-group"},
+	{kind: CacheModelSlice, listPath: "/v1/network-slice"},
+}
+
+// WithFetcher sets the fetcher for reading back from the core
+func WithFetcher(fetcher FetcherInterface) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.fetcher = fetcher
+	}
+}
+
+// WithReconcileInterval sets the interval between reconciliation passes. Zero disables
+// periodic reconciliation.
+func WithReconcileInterval(reconcileInterval time.Duration) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.reconcileInterval = reconcileInterval
+	}
+}
+
+// WithReconcileSafeMode sets whether reconciliation only reports orphans, rather than
+// deleting them
+func WithReconcileSafeMode(safeMode bool) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.reconcileSafeMode = safeMode
+	}
+}
+
+// ReconcileSafeMode returns true if reconciliation only reports orphans
+func (s *Synchronizer) ReconcileSafeMode() bool {
+	return s.reconcileSafeMode
+}
+
+// GetReconcileReport returns the report from the most recent reconciliation, or nil
+func (s *Synchronizer) GetReconcileReport() *ReconcileReport {
+	s.reconcileMutex.Lock()
+	defer s.reconcileMutex.Unlock()
+
+	return s.reconcileReport
+}
+
+// setLastConfig remembers the most recent config, for reconciliation
+func (s *Synchronizer) setLastConfig(config ygot.ValidatedGoStruct) {
+	s.reconcileMutex.Lock()
+	defer s.reconcileMutex.Unlock()
+
+	s.lastConfig = config
+}
+
+// expectedObjects returns the ids of each kind that the model places on a connectivity service
+func expectedObjects(device *RootDevice, csID string) map[string]map[string]bool {
+	expected := map[string]map[string]bool{}
+	for _, rk := range reconcileKinds {
+		expected[rk.kind] = map[string]bool{}
+	}
+
+	if device.Enterprises == nil {
+		return expected
+	}
+
+	// This follows SynchronizeDevice; an enterprise that names the connectivity service
+	// has its objects pushed there.
+	for _, enterprise := range device.Enterprises.Enterprise {
+		if _, okay := enterprise.ConnectivityService[csID]; !okay {
+			continue
+		}
+		for _, site := range enterprise.Site {
+			for dgID := range site.DeviceGroup {
+				expected[CacheModelDeviceGroup][dgID] = true
+			}
+			for sliceID := range site.Slice {
+				expected[CacheModelSlice][sliceID] = true
+			}
+		}
+	}
+
+	return expected
+}
+
+// Reconcile lists the device-groups and slices on each connectivity service's core, and
+// deletes those that are not in the most recent config. In safe mode, the orphans are only
+// reported. The core is expected to list each kind as a JSON array of names.
+func (s *Synchronizer) Reconcile(safeMode bool) (*ReconcileReport, error) {
+	s.reconcileMutex.Lock()
+	config := s.lastConfig
+	s.reconcileMutex.Unlock()
+
+	if config == nil {
+		return nil, fmt.Errorf("No configuration has been received yet")
+	}
+	if s.fetcher == nil {
+		return nil, fmt.Errorf("No fetcher is configured")
+	}
+
+	device, okay := config.(*RootDevice)
+	if !okay {
+		return nil, fmt.Errorf("Configuration is not a RootDevice")
+	}
+
+	report := &ReconcileReport{Time: time.Now(), SafeMode: safeMode, Orphans: []Orphan{}}
+
+	if device.ConnectivityServices != nil {
+		csIDs := []string{}
+		for csID := range device.ConnectivityServices.ConnectivityService {
+			csIDs = append(csIDs, csID)
+		}
+		sort.Strings(csIDs)
+
+		for _, csID := range csIDs {
+			cs := device.ConnectivityServices.ConnectivityService[csID]
+			if cs.Core_5GEndpoint == nil {
+				continue
+			}
+			s.reconcileConnectivityService(device, cs, safeMode, report)
+		}
+	}
+
+	s.cacheFlush()
+
+	for _, orphan := range report.Orphans {
+		KpiOrphansTotal.WithLabelValues(orphan.ConnectivityService, orphan.Kind).Inc()
+	}
+
+	s.reconcileMutex.Lock()
+	s.reconcileReport = report
+	s.reconcileMutex.Unlock()
+
+	return report, nil
+}
+
+// reconcileConnectivityService reconciles one connectivity service, adding to report
+func (s *Synchronizer) reconcileConnectivityService(device *RootDevice, cs *ConnectivityService, safeMode bool, report *ReconcileReport) {
+	csID := *cs.ConnectivityServiceId
+	expected := expectedObjects(device, csID)
+
+	for _, rk := range reconcileKinds {
+		listURL := *cs.Core_5GEndpoint + rk.listPath
+		data, err := s.fetcher.Fetch(listURL)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("Failed to list %s on %s: %v", rk.kind, csID, err))
+			continue
+		}
+
+		var ids []string
+		err = json.Unmarshal(data, &ids)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("Failed to parse %s list from %s: %v", rk.kind, listURL, err))
+			continue
+		}
+		sort.Strings(ids)
+
+		for _, id := range ids {
+			if expected[rk.kind][id] {
+				continue
+			}
+
+			orphan := Orphan{
+				Kind:                rk.kind,
+				ID:                  id,
+				ConnectivityService: csID,
+				Endpoint:            fmt.Sprintf("%s/%s", listURL, id),
+			}
+
+			if safeMode {
+				log.Infof("Reconcile: %s %s on %s is an orphan", rk.kind, id, csID)
+			} else {
+				log.Infof("Reconcile: deleting orphan %s %s from %s", rk.kind, id, csID)
+				err = s.pushDelete(rk.kind, id, csID, orphan.Endpoint)
+				if err != nil {
+					pushError, ok := err.(*PushError)
+					if !ok || pushError.StatusCode != 404 {
+						orphan.Error = err.Error()
+					}
+				}
+				if orphan.Error == "" {
+					orphan.Deleted = true
+					s.CacheDelete(rk.kind, id)
+				}
+			}
+
+			report.Orphans = append(report.Orphans, orphan)
+		}
+	}
+}
+
+// reconcileLoop runs reconciliation every reconcileInterval
+func (s *Synchronizer) reconcileLoop() {
+	log.Infof("Starting reconcile loop, interval=%s, safeMode=%v", s.reconcileInterval, s.reconcileSafeMode)
+	ticker := time.NewTicker(s.reconcileInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		report, err := s.Reconcile(s.reconcileSafeMode)
+		if err != nil {
+			log.Warnf("Reconcile failed: %v", err)
+			continue
+		}
+		log.Infof("Reconcile found %d orphans", len(report.Orphans))
+	}
+}
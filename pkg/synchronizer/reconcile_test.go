@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestReconcileSafeMode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	mockFetcher := mocks.NewMockFetcherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithFetcher(mockFetcher))
+
+	_, err := s.Reconcile(true)
+	assert.EqualError(t, err, "No configuration has been received yet")
+	assert.Nil(t, s.GetReconcileReport())
+
+	s.setLastConfig(BuildSampleDevice())
+
+	mockFetcher.EXPECT().Fetch("http://5gcore/v1/device-group").Return([]byte(`["sample-dg", "old-dg"]`), nil)
+	mockFetcher.EXPECT().Fetch("http://5gcore/v1/network-slice").Return([]byte(`["sample-slice"]`), nil)
+
+	// Nothing is deleted
+	report, err := s.Reconcile(true)
+	assert.Nil(t, err)
+	assert.True(t, report.SafeMode)
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, []Orphan{{
+		Kind:                CacheModelDeviceGroup,
+		ID:                  "old-dg",
+		ConnectivityService: "sample-cs",
+		Endpoint:            "http://5gcore/v1/device-group/old-dg",
+	}}, report.Orphans)
+	assert.Equal(t, report, s.GetReconcileReport())
+}
+
+func TestReconcileDelete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	mockFetcher := mocks.NewMockFetcherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithFetcher(mockFetcher))
+	s.setLastConfig(BuildSampleDevice())
+
+	s.cache[cacheKey(CacheModelSlice, "old-slice")] = []byte(`{}`)
+
+	mockFetcher.EXPECT().Fetch("http://5gcore/v1/device-group").Return([]byte(`["sample-dg", "old-dg"]`), nil)
+	mockFetcher.EXPECT().Fetch("http://5gcore/v1/network-slice").Return([]byte(`["old-slice", "sample-slice", "stuck-slice"]`), nil)
+	mockPusher.EXPECT().PushDelete("http://5gcore/v1/device-group/old-dg").Return(&PushError{StatusCode: 404})
+	mockPusher.EXPECT().PushDelete("http://5gcore/v1/network-slice/old-slice").Return(nil)
+	mockPusher.EXPECT().PushDelete("http://5gcore/v1/network-slice/stuck-slice").Return(errors.New("down"))
+
+	report, err := s.Reconcile(false)
+	assert.Nil(t, err)
+	assert.False(t, report.SafeMode)
+	assert.Equal(t, 3, len(report.Orphans))
+
+	// Already gone counts as deleted
+	assert.Equal(t, "old-dg", report.Orphans[0].ID)
+	assert.True(t, report.Orphans[0].Deleted)
+
+	assert.Equal(t, "old-slice", report.Orphans[1].ID)
+	assert.True(t, report.Orphans[1].Deleted)
+	_, okay := s.cache[cacheKey(CacheModelSlice, "old-slice")]
+	assert.False(t, okay)
+
+	assert.Equal(t, "stuck-slice", report.Orphans[2].ID)
+	assert.False(t, report.Orphans[2].Deleted)
+	assert.Equal(t, "down", report.Orphans[2].Error)
+}
+
+func TestReconcileFetchError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	mockFetcher := mocks.NewMockFetcherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithFetcher(mockFetcher))
+	s.setLastConfig(BuildSampleDevice())
+
+	mockFetcher.EXPECT().Fetch("http://5gcore/v1/device-group").Return(nil, errors.New("down"))
+	mockFetcher.EXPECT().Fetch("http://5gcore/v1/network-slice").Return([]byte(`not json`), nil)
+
+	report, err := s.Reconcile(false)
+	assert.Nil(t, err)
+	assert.Empty(t, report.Orphans)
+	assert.Equal(t, 2, len(report.Errors))
+}
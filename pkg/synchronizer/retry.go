@@ -0,0 +1,329 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Retry implements a work queue of failed pushes, keyed by southbound object. Each object
+// is retried on its own exponential backoff, by a loop that runs apart from the
+// synchronizer loop, so that a stream of updates cannot keep a failing object from being
+// retried. While an object is backing off, or is being pushed, newer versions of it
+// replace the queued one rather than being pushed, so that the retry always pushes the
+// latest config and a stream of updates cannot bypass the backoff.
+
+package synchronizer
+
+import (
+	"bytes"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/openconfig/ygot/ygot"
+)
+
+// retryItem is a failed push that is waiting to be retried
+type retryItem struct {
+	kind        string
+	id          string
+	cs          string
+	endpoint    string
+	data        []byte
+	attempts    int
+	nextAttempt time.Time
+
+	// inFlight is true while the queued version is being pushed
+	inFlight bool
+}
+
+// WithRetryInterval sets the delay before the first retry of a failed push. Each
+// subsequent retry of the same object doubles the delay.
+func WithRetryInterval(retryInterval time.Duration) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.retryInterval = retryInterval
+	}
+}
+
+// WithRetryMaxInterval sets the cap on the delay between retries
+func WithRetryMaxInterval(retryMaxInterval time.Duration) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.retryMaxInterval = retryMaxInterval
+	}
+}
+
+// retryBackoff returns the delay before the next retry of something that has failed
+// attempts times.
+func (s *Synchronizer) retryBackoff(attempts int) time.Duration {
+	delay := s.retryInterval
+	for i := 1; (i < attempts) && (delay < s.retryMaxInterval); i++ {
+		delay *= 2
+	}
+	if delay > s.retryMaxInterval {
+		delay = s.retryMaxInterval
+	}
+
+	// Shave off a random part of the delay, so that objects that failed together
+	// do not all retry together.
+	jitter := time.Duration(rand.Int63n(int64(float64(delay)*RetryJitter) + 1))
+	return delay - jitter
+}
+
+// retryAdd schedules a retry of a failed update
+func (s *Synchronizer) retryAdd(kind string, id string, cs string, endpoint string, data []byte) {
+	s.retryMutex.Lock()
+	defer s.retryMutex.Unlock()
+
+	s.retryAddLocked(kind, id, cs, endpoint, data)
+}
+
+// retryAddLocked schedules a retry of a failed update. Caller must hold retryMutex.
+func (s *Synchronizer) retryAddLocked(kind string, id string, cs string, endpoint string, data []byte) {
+	key := statusKey(kind, id, cs)
+	item, okay := s.retryQueue[key]
+	if !okay {
+		item = &retryItem{kind: kind, id: id, cs: cs}
+		s.retryQueue[key] = item
+		KpiRetryPending.WithLabelValues(cs, kind).Inc()
+	}
+	item.endpoint = endpoint
+	item.data = data
+	item.attempts++
+	item.inFlight = false
+	item.nextAttempt = time.Now().Add(s.retryBackoff(item.attempts))
+	s.retryWake()
+}
+
+// retryWake wakes the retry loop, so that it sees a change to the queue
+func (s *Synchronizer) retryWake() {
+	select {
+	case s.wakeChannel <- struct{}{}:
+	default:
+	}
+}
+
+// retryRemove cancels any retry of (kind, id, cs). An empty cs cancels the retries for
+// every connectivity service.
+func (s *Synchronizer) retryRemove(kind string, id string, cs string) {
+	s.retryMutex.Lock()
+	defer s.retryMutex.Unlock()
+
+	for key, item := range s.retryQueue {
+		if (item.kind == kind) && (item.id == id) && ((cs == "") || (item.cs == cs)) {
+			delete(s.retryQueue, key)
+			KpiRetryPending.WithLabelValues(item.cs, item.kind).Dec()
+		}
+	}
+}
+
+// retryDefer is called before data is pushed for (kind, id, cs). If the object is waiting
+// for a retry that is not yet due, or is being pushed, data replaces the queued version and
+// true is returned; the push is left to the retry. Otherwise, a retry that is due is
+// claimed by the caller, which must pass the result of its push to retryFinish.
+func (s *Synchronizer) retryDefer(kind string, id string, cs string, endpoint string, data []byte) bool {
+	s.retryMutex.Lock()
+	defer s.retryMutex.Unlock()
+
+	item, okay := s.retryQueue[statusKey(kind, id, cs)]
+	if !okay {
+		return false
+	}
+	item.endpoint = endpoint
+	item.data = data
+	if !item.inFlight && !time.Now().Before(item.nextAttempt) {
+		item.inFlight = true
+		return false
+	}
+	return true
+}
+
+// retryFinish records the result of pushing data for (kind, id, cs). A failed push is
+// queued to be retried. If a newer version was queued while data was being pushed, the
+// newer version is kept, and is due at once. Returns true if data was pushed and is still
+// the latest version, so that the caller may cache it.
+func (s *Synchronizer) retryFinish(kind string, id string, cs string, endpoint string, data []byte, err error) bool {
+	s.retryMutex.Lock()
+	defer s.retryMutex.Unlock()
+
+	key := statusKey(kind, id, cs)
+	item, okay := s.retryQueue[key]
+	if !okay {
+		if err != nil {
+			s.retryAddLocked(kind, id, cs, endpoint, data)
+		}
+		return err == nil
+	}
+
+	superseded := !bytes.Equal(item.data, data)
+	switch {
+	case err != nil:
+		item.attempts++
+		item.nextAttempt = time.Now().Add(s.retryBackoff(item.attempts))
+	case superseded:
+		log.Infof("%s %s changed while it was being pushed, pushing the latest version", kind, id)
+		item.attempts = 0
+		item.nextAttempt = time.Now()
+	default:
+		delete(s.retryQueue, key)
+		KpiRetryPending.WithLabelValues(cs, kind).Dec()
+	}
+	item.inFlight = false
+	s.retryWake()
+
+	return (err == nil) && !superseded
+}
+
+// retryExpedite makes every pending retry due now. The retry loop is not woken, so that
+// the synchronization that follows pushes the latest version of each object itself.
+func (s *Synchronizer) retryExpedite() {
+	s.retryMutex.Lock()
+	defer s.retryMutex.Unlock()
+
+	now := time.Now()
+	for _, item := range s.retryQueue {
+		item.nextAttempt = now
+	}
+}
+
+// retryPrune cancels the retries of objects that are not in config. Retries of objects
+// that are still in config are kept; the next pass replaces them with the latest version.
+func (s *Synchronizer) retryPrune(config ygot.ValidatedGoStruct) {
+	device, okay := config.(*RootDevice)
+	if !okay {
+		return
+	}
+
+	keep := map[string]bool{}
+	for _, scope := range deviceScopes(device) {
+		cs := *scope.ConnectivityService.ConnectivityServiceId
+		for dgID := range scope.Site.DeviceGroup {
+			keep[statusKey(CacheModelDeviceGroup, dgID, cs)] = true
+		}
+		for sliceID, slice := range scope.Site.Slice {
+			keep[statusKey(CacheModelSlice, sliceID, cs)] = true
+			for i, upfID := range s.upfCandidateIDs(scope, slice) {
+				keep[statusKey(CacheModelSliceUpf, upfSliceID(sliceID, upfID, i == 0), cs)] = true
+			}
+		}
+	}
+
+	s.retryMutex.Lock()
+	defer s.retryMutex.Unlock()
+
+	for key, item := range s.retryQueue {
+		if !keep[key] {
+			log.Infof("Cancelling retry of %s %s, which is no longer in the config", item.kind, item.id)
+			delete(s.retryQueue, key)
+			KpiRetryPending.WithLabelValues(item.cs, item.kind).Dec()
+		}
+	}
+}
+
+// retryPending returns the number of failed pushes waiting to be retried
+func (s *Synchronizer) retryPending() int {
+	s.retryMutex.Lock()
+	defer s.retryMutex.Unlock()
+
+	return len(s.retryQueue)
+}
+
+// retryNextDelay returns how long until the next retry is due, and false if there is no
+// retry to wait for
+func (s *Synchronizer) retryNextDelay() (time.Duration, bool) {
+	s.retryMutex.Lock()
+	defer s.retryMutex.Unlock()
+
+	var next time.Time
+	for _, item := range s.retryQueue {
+		if item.inFlight {
+			// Whoever is pushing it reports back to retryFinish
+			continue
+		}
+		if next.IsZero() || item.nextAttempt.Before(next) {
+			next = item.nextAttempt
+		}
+	}
+	if next.IsZero() {
+		return 0, false
+	}
+
+	delay := time.Until(next)
+	if delay < 0 {
+		delay = 0
+	}
+	return delay, true
+}
+
+// retryDue retries every push that is due. Objects that fail again are rescheduled with a
+// longer delay; objects that succeed are added to the cache. Returns true if a slice was
+// pushed to the core, as the push of the slice to its UPF was skipped when it failed.
+func (s *Synchronizer) retryDue() bool {
+	now := time.Now()
+
+	s.retryMutex.Lock()
+	due := []retryItem{}
+	for _, item := range s.retryQueue {
+		if !item.inFlight && !item.nextAttempt.After(now) {
+			item.inFlight = true
+			due = append(due, *item)
+		}
+	}
+	s.retryMutex.Unlock()
+
+	slicePushed := false
+	for _, item := range due {
+		log.Infof("Retrying %s %s to %s (attempt %d)", item.kind, item.id, item.endpoint, item.attempts+1)
+		KpiRetryTotal.WithLabelValues(item.cs, item.kind).Inc()
+
+		err := s.pusher.PushUpdate(item.endpoint, item.data)
+		s.statusPushResult(item.kind, item.id, item.cs, item.endpoint, item.data, err)
+		s.upfPushResult(item.kind, item.endpoint, err)
+		if err != nil {
+			log.Warnf("Retry of %s %s failed: %v", item.kind, item.id, err)
+		}
+
+		if s.retryFinish(item.kind, item.id, item.cs, item.endpoint, item.data, err) {
+			s.cacheUpdateJSON(item.kind, item.id, item.data)
+			if item.kind == CacheModelSlice {
+				slicePushed = true
+			}
+		}
+	}
+	s.cacheFlush()
+
+	return slicePushed
+}
+
+// retrySleep waits for delay. Returns false if it was interrupted by a change to the queue.
+func (s *Synchronizer) retrySleep(delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-s.wakeChannel:
+		return false
+	}
+}
+
+// retryLoop pushes failed objects again as their retries fall due. When a retried slice
+// reaches the core, the latest config is synchronized again, to push what was skipped
+// because the slice had failed.
+func (s *Synchronizer) retryLoop() {
+	log.Infof("Starting retry loop")
+	for {
+		delay, okay := s.retryNextDelay()
+		if !okay {
+			<-s.wakeChannel
+			continue
+		}
+		if !s.retrySleep(delay) {
+			continue
+		}
+
+		// Busy until any synchronization that follows has been queued
+		atomic.AddInt32(&s.busy, 1)
+		if s.retryDue() {
+			s.requestResync()
+		}
+		atomic.AddInt32(&s.busy, -1)
+	}
+}
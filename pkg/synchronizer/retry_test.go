@@ -0,0 +1,206 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	s := NewSynchronizer()
+	assert.Equal(t, DefaultRetryInterval, s.retryInterval)
+	assert.Equal(t, DefaultRetryMaxInterval, s.retryMaxInterval)
+
+	s = NewSynchronizer(WithRetryInterval(time.Second), WithRetryMaxInterval(10*time.Second))
+	for i := 0; i < 100; i++ {
+		delay := s.retryBackoff(1)
+		assert.True(t, delay > 790*time.Millisecond && delay <= time.Second, "attempt 1 delay %s", delay)
+		delay = s.retryBackoff(3)
+		assert.True(t, delay > 3190*time.Millisecond && delay <= 4*time.Second, "attempt 3 delay %s", delay)
+		delay = s.retryBackoff(20)
+		assert.True(t, delay > 7990*time.Millisecond && delay <= 10*time.Second, "attempt 20 delay %s", delay)
+	}
+}
+
+// pushCounter counts the pushes to each endpoint, which are made from both the
+// synchronizer loop and the retry loop
+type pushCounter struct {
+	mu     sync.Mutex
+	pushes map[string]int
+	last   map[string]string
+}
+
+func (c *pushCounter) record(endpoint string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pushes[endpoint]++
+	c.last[endpoint] = string(data)
+}
+
+func (c *pushCounter) count(endpoint string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pushes[endpoint]
+}
+
+func newPushCounter() *pushCounter {
+	return &pushCounter{pushes: map[string]int{}, last: map[string]string{}}
+}
+
+// failingPusher returns a mock pusher that fails the first failures pushes to endpoint
+func failingPusher(t *testing.T, counter *pushCounter, endpoint string, failures int) *mocks.MockPusherInterface {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	mockPusher.EXPECT().PushUpdate(endpoint, gomock.Any()).DoAndReturn(func(endpoint string, data []byte) error {
+		counter.record(endpoint, data)
+		counter.mu.Lock()
+		defer counter.mu.Unlock()
+		if failures > 0 {
+			failures--
+			return &PushError{Operation: "POST", Endpoint: endpoint, StatusCode: 503, Status: "503 Service Unavailable"}
+		}
+		return nil
+	}).AnyTimes()
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).DoAndReturn(func(endpoint string, data []byte) error {
+		counter.record(endpoint, data)
+		return nil
+	}).AnyTimes()
+	return mockPusher
+}
+
+// Only the failed push is retried, and the healthy endpoints are left alone
+func TestSynchronizeAndRetryPerObject(t *testing.T) {
+	counter := newPushCounter()
+	mockPusher := failingPusher(t, counter, "http://5gcore/v1/device-group/sample-dg", 2)
+	s := NewSynchronizer(WithPusher(mockPusher), WithRetryInterval(10*time.Millisecond))
+	s.Start()
+
+	err := s.Synchronize(BuildSampleDevice(), gnmi.Apply, nil)
+	assert.Nil(t, err)
+	waitForSyncIdle(t, s, 5*time.Second)
+
+	assert.Equal(t, 3, counter.count("http://5gcore/v1/device-group/sample-dg"))
+	assert.Equal(t, 1, counter.count("http://5gcore/v1/network-slice/sample-slice"))
+	assert.Equal(t, 1, counter.count("http://upf/v1/config/network-slices"))
+	assert.Equal(t, 0, s.retryPending())
+
+	// The retried device-group made it into the cache
+	dgStatus := s.GetStatus(CacheModelDeviceGroup, "sample-dg")
+	assert.Equal(t, StatusSynchronized, dgStatus[0].State)
+	assert.Equal(t, 3, dgStatus[0].Attempts)
+	s.cacheMutex.Lock()
+	_, okay := s.cache[cacheKey(CacheModelDeviceGroup, "sample-dg")]
+	s.cacheMutex.Unlock()
+	assert.True(t, okay)
+}
+
+// A UPF push that was skipped because the core slice failed is made once the retry succeeds
+func TestSynchronizeAndRetrySkippedUpf(t *testing.T) {
+	counter := newPushCounter()
+	mockPusher := failingPusher(t, counter, "http://5gcore/v1/network-slice/sample-slice", 1)
+	s := NewSynchronizer(WithPusher(mockPusher), WithRetryInterval(10*time.Millisecond))
+	s.Start()
+
+	err := s.Synchronize(BuildSampleDevice(), gnmi.Apply, nil)
+	assert.Nil(t, err)
+	waitForSyncIdle(t, s, 5*time.Second)
+
+	assert.Equal(t, 1, counter.count("http://5gcore/v1/device-group/sample-dg"))
+	assert.Equal(t, 2, counter.count("http://5gcore/v1/network-slice/sample-slice"))
+	assert.Equal(t, 1, counter.count("http://upf/v1/config/network-slices"))
+}
+
+// A stream of updates does not keep a failed push from being retried, and the retry ends
+// with the latest version on the core
+func TestRetryNotStarvedByUpdates(t *testing.T) {
+	counter := newPushCounter()
+	mockPusher := failingPusher(t, counter, "http://5gcore/v1/device-group/sample-dg", 3)
+	s := NewSynchronizer(WithPusher(mockPusher), WithRetryInterval(10*time.Millisecond), WithRetryMaxInterval(20*time.Millisecond))
+	s.Start()
+
+	device := BuildSampleDevice()
+	ipd := device.Enterprises.Enterprise["sample-ent"].Site["sample-site"].IpDomain["sample-ipd"]
+	for i := 0; i < 50; i++ {
+		ipd.DnsPrimary = aStr(fmt.Sprintf("10.0.0.%d", i))
+		err := s.Synchronize(device, gnmi.Apply, nil)
+		assert.Nil(t, err)
+		time.Sleep(2 * time.Millisecond)
+	}
+	waitForSyncIdle(t, s, 5*time.Second)
+
+	assert.Equal(t, 0, s.retryPending())
+	dgStatus := s.GetStatus(CacheModelDeviceGroup, "sample-dg")
+	assert.Equal(t, StatusSynchronized, dgStatus[0].State)
+	counter.mu.Lock()
+	assert.Contains(t, counter.last["http://5gcore/v1/device-group/sample-dg"], "10.0.0.49")
+	counter.mu.Unlock()
+}
+
+// While a device-group is backing off, newer versions replace the queued one instead of
+// being pushed, and the retry pushes the latest version
+func TestRetryLatestVersionWins(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithRetryInterval(time.Hour))
+
+	dgPushes := []string{}
+	dgUp := false
+	mockPusher.EXPECT().PushUpdate("http://5gcore/v1/device-group/sample-dg", gomock.Any()).DoAndReturn(func(endpoint string, data []byte) error {
+		dgPushes = append(dgPushes, string(data))
+		if !dgUp {
+			return &PushError{Operation: "PUT", Endpoint: endpoint, StatusCode: 503, Status: "503 Service Unavailable"}
+		}
+		return nil
+	}).AnyTimes()
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	device := BuildSampleDevice()
+	pushErrors, err := s.SynchronizeDevice(device)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, pushErrors)
+	assert.Equal(t, 1, len(dgPushes))
+	assert.Equal(t, 1, s.retryPending())
+
+	// Two more updates arrive while the device-group is backing off. Queuing them is not
+	// a failure.
+	ipd := device.Enterprises.Enterprise["sample-ent"].Site["sample-site"].IpDomain["sample-ipd"]
+	ipd.DnsPrimary = aStr("1.1.1.1")
+	pushErrors, err = s.SynchronizeDevice(device)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, pushErrors)
+	ipd.DnsPrimary = aStr("9.9.9.9")
+	pushErrors, err = s.SynchronizeDevice(device)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, pushErrors)
+	assert.Equal(t, 1, len(dgPushes))
+	assert.Equal(t, 1, s.retryPending())
+
+	dgUp = true
+	s.retryExpedite()
+	s.retryDue()
+	assert.Equal(t, 2, len(dgPushes))
+	assert.Contains(t, dgPushes[1], "9.9.9.9")
+	assert.Equal(t, 0, s.retryPending())
+}
+
+// Retries of objects that are no longer in the config are dropped
+func TestRetryPrune(t *testing.T) {
+	s := NewSynchronizer(WithRetryInterval(time.Hour))
+	s.retryAdd(CacheModelDeviceGroup, "sample-dg", "sample-cs", "http://5gcore/v1/device-group/sample-dg", []byte("{}"))
+	s.retryAdd(CacheModelDeviceGroup, "old-dg", "sample-cs", "http://5gcore/v1/device-group/old-dg", []byte("{}"))
+	s.retryAdd(CacheModelSliceUpf, "sample-slice", "sample-cs", "http://upf/v1/config/network-slices", []byte("{}"))
+
+	s.retryPrune(BuildSampleDevice())
+	assert.Equal(t, 2, s.retryPending())
+	assert.False(t, s.retryDefer(CacheModelDeviceGroup, "old-dg", "sample-cs", "", nil))
+	assert.True(t, s.retryDefer(CacheModelDeviceGroup, "sample-dg", "sample-cs", "", nil))
+}
@@ -0,0 +1,265 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Rule compiler implements checking the application filtering rules of a slice for
+// conflicts, and optionally reassigning their priorities so that there are none.
+
+package synchronizer
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+const (
+	// RulePriorityPassthrough renders rules with the priorities they are given, and reports
+	// conflicts as warnings
+	RulePriorityPassthrough = "passthrough"
+
+	// RulePriorityReassign renders the application rules first, in order of priority and
+	// then name, followed by the default-behavior rules, and numbers them so that no two
+	// rules have the same priority
+	RulePriorityReassign = "reassign"
+
+	// RulePriorityStrict fails to render a slice whose rules conflict
+	RulePriorityStrict = "strict"
+
+	// DefaultRulePriorityMode is the default rule priority mode
+	DefaultRulePriorityMode = RulePriorityPassthrough
+
+	// ConflictDuplicatePriority is two overlapping application rules with the same priority,
+	// whose order is therefore up to the core
+	ConflictDuplicatePriority = "duplicate-priority"
+
+	// ConflictShadowed is a rule that never matches, because a rule before it covers it
+	ConflictShadowed = "shadowed"
+
+	// ConflictDefaultBehavior is an application rule that is masked, in whole or in part,
+	// by a default-behavior rule
+	ConflictDefaultBehavior = "default-behavior"
+)
+
+// RuleConflict is a problem with the order of two application filtering rules of a slice.
+// Rule is the rule that does not match as intended, because of By.
+type RuleConflict struct {
+	Kind    string `json:"kind"`
+	Rule    string `json:"rule"`
+	By      string `json:"by"`
+	Message string `json:"message"`
+}
+
+// ValidRulePriorityMode returns true if mode is one of the rule priority modes
+func ValidRulePriorityMode(mode string) bool {
+	switch mode {
+	case RulePriorityPassthrough, RulePriorityReassign, RulePriorityStrict:
+		return true
+	}
+	return false
+}
+
+// WithRulePriorityMode sets how the priorities of application filtering rules are
+// assigned, and what is done about conflicts between them
+func WithRulePriorityMode(mode string) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.rulePriorityMode = mode
+	}
+}
+
+// compiledRule is an application filtering rule, and where it came from
+type compiledRule struct {
+	rule            appFilterRule
+	source          string
+	defaultBehavior bool
+	prefix          *net.IPNet
+}
+
+// portRange returns the port range of the rule, and false if it matches every port
+func (c *compiledRule) portRange() (uint16, uint16, bool) {
+	if c.rule.DestPortStart == nil {
+		return 0, 0, false
+	}
+	end := *c.rule.DestPortStart
+	if c.rule.DestPortEnd != nil {
+		end = *c.rule.DestPortEnd
+	}
+	return *c.rule.DestPortStart, end, true
+}
+
+// overlaps returns true if some packet matches both rules
+func (c *compiledRule) overlaps(o *compiledRule) bool {
+	if (c.prefix == nil) || (o.prefix == nil) {
+		return false
+	}
+	// Two prefixes overlap only if one contains the other
+	if !c.prefix.Contains(o.prefix.IP) && !o.prefix.Contains(c.prefix.IP) {
+		return false
+	}
+	if (c.rule.Protocol != nil) && (o.rule.Protocol != nil) && (*c.rule.Protocol != *o.rule.Protocol) {
+		return false
+	}
+	cStart, cEnd, cPorts := c.portRange()
+	oStart, oEnd, oPorts := o.portRange()
+	if cPorts && oPorts && ((cEnd < oStart) || (oEnd < cStart)) {
+		return false
+	}
+	return true
+}
+
+// covers returns true if every packet that matches o also matches c
+func (c *compiledRule) covers(o *compiledRule) bool {
+	if (c.prefix == nil) || (o.prefix == nil) {
+		return false
+	}
+	cOnes, cBits := c.prefix.Mask.Size()
+	oOnes, oBits := o.prefix.Mask.Size()
+	if (cBits != oBits) || (cOnes > oOnes) || !c.prefix.Contains(o.prefix.IP) {
+		return false
+	}
+	if (c.rule.Protocol != nil) && ((o.rule.Protocol == nil) || (*c.rule.Protocol != *o.rule.Protocol)) {
+		return false
+	}
+	cStart, cEnd, cPorts := c.portRange()
+	oStart, oEnd, oPorts := o.portRange()
+	if cPorts && (!oPorts || (oStart < cStart) || (oEnd > cEnd)) {
+		return false
+	}
+	return true
+}
+
+// sameSource returns true if both rules come from the same application, or both from the
+// default-behavior. Rules from one source are not checked against each other.
+func (c *compiledRule) sameSource(o *compiledRule) bool {
+	return (c.source == o.source) && (c.defaultBehavior == o.defaultBehavior)
+}
+
+// ruleConflicts returns the conflicts between rules. A lower priority is matched first.
+func ruleConflicts(rules []*compiledRule) []RuleConflict {
+	conflicts := []RuleConflict{}
+	for i, a := range rules {
+		for j, b := range rules {
+			if (i == j) || a.sameSource(b) {
+				continue
+			}
+			switch {
+			case (a.rule.Priority == b.rule.Priority) && (i < j) && a.overlaps(b):
+				kind := ConflictDuplicatePriority
+				rule, by := b, a
+				if a.defaultBehavior != b.defaultBehavior {
+					kind = ConflictDefaultBehavior
+					if !a.defaultBehavior {
+						rule, by = a, b
+					}
+				}
+				conflicts = append(conflicts, RuleConflict{
+					Kind:    kind,
+					Rule:    rule.rule.Name,
+					By:      by.rule.Name,
+					Message: fmt.Sprintf("rule %s and rule %s overlap and have the same priority %d", rule.rule.Name, by.rule.Name, a.rule.Priority),
+				})
+			case (a.rule.Priority < b.rule.Priority) && a.covers(b):
+				kind := ConflictShadowed
+				if a.defaultBehavior && !b.defaultBehavior {
+					kind = ConflictDefaultBehavior
+				}
+				conflicts = append(conflicts, RuleConflict{
+					Kind:    kind,
+					Rule:    b.rule.Name,
+					By:      a.rule.Name,
+					Message: fmt.Sprintf("rule %s never matches, as rule %s with priority %d covers it", b.rule.Name, a.rule.Name, a.rule.Priority),
+				})
+			case (a.rule.Priority < b.rule.Priority) && a.defaultBehavior && !b.defaultBehavior && (a.rule.Action != b.rule.Action) && a.overlaps(b):
+				conflicts = append(conflicts, RuleConflict{
+					Kind:    ConflictDefaultBehavior,
+					Rule:    b.rule.Name,
+					By:      a.rule.Name,
+					Message: fmt.Sprintf("rule %s is partly masked by default-behavior rule %s with priority %d", b.rule.Name, a.rule.Name, a.rule.Priority),
+				})
+			}
+		}
+	}
+	return conflicts
+}
+
+// reassignPriorities orders the application rules by priority and then name, followed by
+// the default-behavior rules in order of priority, and numbers them from 1. A
+// default-behavior rule keeps its priority if that is not already taken.
+func reassignPriorities(rules []*compiledRule) ([]*compiledRule, error) {
+	appRules := []*compiledRule{}
+	defaultRules := []*compiledRule{}
+	for _, rule := range rules {
+		if rule.defaultBehavior {
+			defaultRules = append(defaultRules, rule)
+		} else {
+			appRules = append(appRules, rule)
+		}
+	}
+
+	sort.SliceStable(appRules, func(i, j int) bool {
+		if appRules[i].rule.Priority != appRules[j].rule.Priority {
+			return appRules[i].rule.Priority < appRules[j].rule.Priority
+		}
+		return appRules[i].rule.Name < appRules[j].rule.Name
+	})
+	sort.SliceStable(defaultRules, func(i, j int) bool {
+		return defaultRules[i].rule.Priority < defaultRules[j].rule.Priority
+	})
+
+	ordered := append(appRules, defaultRules...)
+	next := 1
+	for _, rule := range ordered {
+		if rule.defaultBehavior && (int(rule.rule.Priority) > next) {
+			next = int(rule.rule.Priority)
+		}
+		if next > 255 {
+			return nil, fmt.Errorf("has too many application filtering rules to assign priorities")
+		}
+		rule.rule.Priority = uint8(next)
+		next++
+	}
+
+	return ordered, nil
+}
+
+// compileRules checks the application filtering rules of a slice for conflicts, and
+// returns the rules to render. The first appRuleCount rules come from the slice's
+// applications, with sources giving the application of each, and the rest from its
+// default-behavior.
+func (s *Synchronizer) compileRules(rules []appFilterRule, sources []string, appRuleCount int) ([]appFilterRule, []RuleConflict, error) {
+	compiled := []*compiledRule{}
+	for i := range rules {
+		c := &compiledRule{
+			rule:            rules[i],
+			source:          sources[i],
+			defaultBehavior: i >= appRuleCount,
+		}
+		// Rules have already been rendered with valid prefixes
+		_, c.prefix, _ = net.ParseCIDR(rules[i].Endpoint)
+		compiled = append(compiled, c)
+	}
+
+	if s.rulePriorityMode == RulePriorityReassign {
+		var err error
+		compiled, err = reassignPriorities(compiled)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	conflicts := ruleConflicts(compiled)
+	if (s.rulePriorityMode == RulePriorityStrict) && (len(conflicts) > 0) {
+		messages := []string{}
+		for _, conflict := range conflicts {
+			messages = append(messages, conflict.Message)
+		}
+		return nil, conflicts, fmt.Errorf("has conflicting application filtering rules: %s", strings.Join(messages, "; "))
+	}
+
+	result := []appFilterRule{}
+	for _, c := range compiled {
+		result = append(result, c.rule)
+	}
+	return result, conflicts, nil
+}
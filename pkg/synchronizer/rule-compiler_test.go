@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// conflictingScope gives the sample slice an application whose permit rule is masked by
+// the slice's DENY-ALL, and one whose rule overlaps sample-app and has the same priority
+func conflictingScope(t *testing.T) (*AetherScope, *Slice) {
+	device := BuildSampleDevice()
+	scope, err := BuildScope(device, "sample-ent", "sample-site", "sample-cs")
+	assert.Nil(t, err)
+	slice := scope.Site.Slice["sample-slice"]
+
+	scope.Enterprise.Application["late-app"] = &Application{
+		ApplicationId: aStr("late-app"),
+		Address:       aStr("1.2.3.0/24"),
+		Endpoint:      map[string]*ApplicationEndpoint{"ep": {EndpointId: aStr("ep")}},
+	}
+	scope.Enterprise.Application["same-app"] = &Application{
+		ApplicationId: aStr("same-app"),
+		Address:       aStr("1.2.3.4"),
+		Endpoint:      map[string]*ApplicationEndpoint{"ep": {EndpointId: aStr("ep"), Protocol: aStr("UDP")}},
+	}
+	slice.Filter["late-app"] = &SliceFilter{Allow: aBool(true), Priority: aUint8(251), Application: aStr("late-app")}
+	slice.Filter["same-app"] = &SliceFilter{Allow: aBool(false), Priority: aUint8(7), Application: aStr("same-app")}
+
+	return scope, slice
+}
+
+func TestRuleConflicts(t *testing.T) {
+	rule := func(name string, priority uint8, endpoint string, protocol *uint8, ports ...uint16) appFilterRule {
+		r := appFilterRule{Name: name, Action: "permit", Priority: priority, Endpoint: endpoint, Protocol: protocol}
+		if len(ports) == 2 {
+			r.DestPortStart = aUint16(ports[0])
+			r.DestPortEnd = aUint16(ports[1])
+		}
+		return r
+	}
+
+	s := NewSynchronizer(WithPusher(mocks.NewMockPusherInterface(gomock.NewController(t))))
+
+	// Disjoint prefixes, protocols and port ranges do not conflict
+	_, conflicts, err := s.compileRules([]appFilterRule{
+		rule("a", 5, "10.0.0.0/8", aUint8(6)),
+		rule("b", 5, "11.0.0.0/8", nil),
+		rule("c", 6, "10.0.0.0/8", aUint8(17), 80, 90),
+		rule("d", 6, "10.0.0.0/8", aUint8(17), 91, 100),
+		rule("e", 8, "2001:db8::/32", nil),
+	}, []string{"a", "b", "c", "d", "e"}, 4)
+	assert.Nil(t, err)
+	assert.Empty(t, conflicts)
+
+	// Rules of the same application do not conflict with each other
+	_, conflicts, _ = s.compileRules([]appFilterRule{
+		rule("a-1", 5, "10.0.0.0/8", nil),
+		rule("a-2", 5, "10.1.0.0/16", nil),
+	}, []string{"a", "a"}, 2)
+	assert.Empty(t, conflicts)
+
+	_, conflicts, _ = s.compileRules([]appFilterRule{
+		rule("wide", 5, "10.0.0.0/8", aUint8(17), 1, 1000),
+		rule("narrow", 6, "10.1.0.0/16", aUint8(17), 80, 90),
+		rule("same", 5, "10.0.0.0/8", nil),
+		rule("any-port", 7, "10.1.0.0/16", aUint8(17)),
+	}, []string{"wide", "narrow", "same", "any-port"}, 4)
+	assert.Equal(t, []RuleConflict{
+		{ConflictShadowed, "narrow", "wide", "rule narrow never matches, as rule wide with priority 5 covers it"},
+		{ConflictDuplicatePriority, "same", "wide", "rule same and rule wide overlap and have the same priority 5"},
+		{ConflictShadowed, "narrow", "same", "rule narrow never matches, as rule same with priority 5 covers it"},
+		{ConflictShadowed, "any-port", "same", "rule any-port never matches, as rule same with priority 5 covers it"},
+	}, conflicts)
+}
+
+func TestRenderSliceRuleConflicts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher))
+
+	// The sample slice has no conflicts, and gets no status for them
+	device := BuildSampleDevice()
+	scope, err := BuildScope(device, "sample-ent", "sample-site", "sample-cs")
+	assert.Nil(t, err)
+	_, err = s.renderSlice(scope, scope.Site.Slice["sample-slice"])
+	assert.Nil(t, err)
+	assert.Empty(t, s.GetStatus(CacheModelSlice, "sample-slice"))
+
+	// Passthrough renders the rules as given, and reports the conflicts
+	scope, slice := conflictingScope(t)
+	coreSlice, err := s.renderSlice(scope, slice)
+	assert.Nil(t, err)
+	priorities := []uint8{}
+	for _, rule := range coreSlice.ApplicationFilteringRules {
+		priorities = append(priorities, rule.Priority)
+	}
+	assert.Equal(t, []uint8{251, 7, 7, 8, 250}, priorities)
+
+	expected := []RuleConflict{
+		{ConflictDuplicatePriority, "sample-app-sample-app-ep", "same-app-ep", "rule sample-app-sample-app-ep and rule same-app-ep overlap and have the same priority 7"},
+		{ConflictDefaultBehavior, "late-app-ep", "DENY-ALL", "rule late-app-ep never matches, as rule DENY-ALL with priority 250 covers it"},
+	}
+	status := s.GetStatus(CacheModelSlice, "sample-slice")
+	assert.Len(t, status, 1)
+	assert.Equal(t, expected, status[0].RuleConflicts)
+
+	// Strict fails the slice
+	s.rulePriorityMode = RulePriorityStrict
+	_, err = s.renderSlice(scope, slice)
+	assert.EqualError(t, err, "Slice sample-slice has conflicting application filtering rules: "+
+		expected[0].Message+"; "+expected[1].Message)
+
+	// Reassign puts the application rules first, in order of priority and then name
+	s.rulePriorityMode = RulePriorityReassign
+	coreSlice, err = s.renderSlice(scope, slice)
+	assert.Nil(t, err)
+	names := []string{}
+	priorities = []uint8{}
+	for _, rule := range coreSlice.ApplicationFilteringRules {
+		names = append(names, rule.Name)
+		priorities = append(priorities, rule.Priority)
+	}
+	assert.Equal(t, []string{"same-app-ep", "sample-app-sample-app-ep", "sample-app2-sample-app2-ep", "late-app-ep", "DENY-ALL"}, names)
+	assert.Equal(t, []uint8{1, 2, 3, 4, 250}, priorities)
+
+	// The conflicts that reassigning fixed are cleared. What remains is that the rule of
+	// same-app, which now comes first, matches every port of sample-app's rule.
+	status = s.GetStatus(CacheModelSlice, "sample-slice")
+	assert.Equal(t, []RuleConflict{
+		{ConflictShadowed, "sample-app-sample-app-ep", "same-app-ep", "rule sample-app-sample-app-ep never matches, as rule same-app-ep with priority 1 covers it"},
+	}, status[0].RuleConflicts)
+}
@@ -0,0 +1,210 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Status implements per-object tracking of synchronization state.
+
+package synchronizer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	// StatusSynchronized means the most recent push of the object succeeded
+	StatusSynchronized = "synchronized"
+
+	// StatusPushFailed means the most recent push of the object failed
+	StatusPushFailed = "push-failed"
+
+	// StatusError means the object could not be converted into something to push
+	StatusError = "error"
+)
+
+// ObjectStatus is the synchronization status of one southbound object on one
+// connectivity service.
+type ObjectStatus struct {
+	Kind                string     `json:"kind"`
+	ID                  string     `json:"id"`
+	ConnectivityService string     `json:"connectivity-service"`
+	Endpoint            string     `json:"endpoint,omitempty"`
+	State               string     `json:"state"`
+	LastAttempt         *time.Time `json:"last-attempt,omitempty"`
+	LastSuccess         *time.Time `json:"last-success,omitempty"`
+	LastError           string     `json:"last-error,omitempty"`
+	Attempts            int        `json:"attempts"`
+	LastPushedHash      string     `json:"last-pushed-hash,omitempty"`
+	Drifted             bool       `json:"drifted,omitempty"`
+	LastDriftCheck      *time.Time `json:"last-drift-check,omitempty"`
+
+	// Conflicts between the application filtering rules of a slice, from its last render
+	RuleConflicts []RuleConflict `json:"rule-conflicts,omitempty"`
+}
+
+// statusKey returns the key used to store (kind, id, cs) in the status map
+func statusKey(kind string, id string, cs string) string {
+	return fmt.Sprintf("%s/%s/%s", kind, id, cs)
+}
+
+// dataHash returns the hash that is reported for a pushed document. The document is
+// compacted first, so that the hash matches the one computed from the cache.
+func dataHash(data []byte) string {
+	compact := &bytes.Buffer{}
+	if err := json.Compact(compact, data); err == nil {
+		data = compact.Bytes()
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// statusGet returns the status for (kind, id, cs), creating it if necessary. Caller must
+// hold statusMutex.
+func (s *Synchronizer) statusGet(kind string, id string, cs string) *ObjectStatus {
+	key := statusKey(kind, id, cs)
+	status, okay := s.status[key]
+	if !okay {
+		status = &ObjectStatus{Kind: kind, ID: id, ConnectivityService: cs}
+		s.status[key] = status
+	}
+	return status
+}
+
+// statusPushResult records the result of pushing data to endpoint
+func (s *Synchronizer) statusPushResult(kind string, id string, cs string, endpoint string, data []byte, err error) {
+	s.statusMutex.Lock()
+	defer s.statusMutex.Unlock()
+
+	now := time.Now()
+	status := s.statusGet(kind, id, cs)
+	status.Endpoint = endpoint
+	status.LastAttempt = &now
+	status.Attempts++
+	if err != nil {
+		status.State = StatusPushFailed
+		status.LastError = err.Error()
+		return
+	}
+	status.State = StatusSynchronized
+	status.LastSuccess = &now
+	status.LastError = ""
+	status.LastPushedHash = dataHash(data)
+	status.Drifted = false
+}
+
+// statusError records an error that prevented (kind, id) from being pushed
+func (s *Synchronizer) statusError(kind string, id string, cs string, err error) {
+	s.statusMutex.Lock()
+	defer s.statusMutex.Unlock()
+
+	now := time.Now()
+	status := s.statusGet(kind, id, cs)
+	status.State = StatusError
+	status.LastAttempt = &now
+	status.LastError = err.Error()
+}
+
+// statusFailing returns the keys of the objects whose most recent render or push failed
+func (s *Synchronizer) statusFailing() map[string]bool {
+	s.statusMutex.Lock()
+	defer s.statusMutex.Unlock()
+
+	failing := map[string]bool{}
+	for key, status := range s.status {
+		if (status.State == StatusPushFailed) || (status.State == StatusError) {
+			failing[key] = true
+		}
+	}
+	return failing
+}
+
+// statusUnchanged records that (kind, id) was not pushed because it matches what is in the
+// cache. This only matters when there is no status yet, which happens when the cache was
+// loaded from the cache store after a restart.
+func (s *Synchronizer) statusUnchanged(kind string, id string, cs string) {
+	s.statusMutex.Lock()
+	defer s.statusMutex.Unlock()
+
+	if _, okay := s.status[statusKey(kind, id, cs)]; okay {
+		return
+	}
+
+	s.cacheMutex.Lock()
+	cached := s.cache[cacheKey(kind, id)]
+	s.cacheMutex.Unlock()
+
+	status := s.statusGet(kind, id, cs)
+	status.State = StatusSynchronized
+	status.LastPushedHash = dataHash(cached)
+}
+
+// statusDrift records the result of comparing (kind, id) on the core against the model
+func (s *Synchronizer) statusDrift(kind string, id string, cs string, endpoint string, drifted bool) {
+	s.statusMutex.Lock()
+	defer s.statusMutex.Unlock()
+
+	now := time.Now()
+	status := s.statusGet(kind, id, cs)
+	if status.State == "" {
+		status.State = StatusSynchronized
+	}
+	status.Endpoint = endpoint
+	status.Drifted = drifted
+	status.LastDriftCheck = &now
+}
+
+// statusRuleConflicts records the conflicts between the application filtering rules of
+// (kind, id). No status is created for an object that has none.
+func (s *Synchronizer) statusRuleConflicts(kind string, id string, cs string, conflicts []RuleConflict) {
+	s.statusMutex.Lock()
+	defer s.statusMutex.Unlock()
+
+	if len(conflicts) == 0 {
+		if status, okay := s.status[statusKey(kind, id, cs)]; okay {
+			status.RuleConflicts = nil
+		}
+		return
+	}
+	s.statusGet(kind, id, cs).RuleConflicts = conflicts
+}
+
+// statusDelete removes the status for (kind, id, cs) once it has been deleted. An empty cs
+// removes the status for every connectivity service.
+func (s *Synchronizer) statusDelete(kind string, id string, cs string) {
+	s.statusMutex.Lock()
+	defer s.statusMutex.Unlock()
+
+	for key, status := range s.status {
+		if (status.Kind == kind) && (status.ID == id) && ((cs == "") || (status.ConnectivityService == cs)) {
+			delete(s.status, key)
+		}
+	}
+}
+
+// GetStatus returns the status of all objects matching kind and id, sorted by kind, id, and
+// connectivity service. An empty kind or id matches everything.
+func (s *Synchronizer) GetStatus(kind string, id string) []ObjectStatus {
+	s.statusMutex.Lock()
+	defer s.statusMutex.Unlock()
+
+	result := []ObjectStatus{}
+	for _, status := range s.status {
+		if (kind != "") && (status.Kind != kind) {
+			continue
+		}
+		if (id != "") && (status.ID != id) {
+			continue
+		}
+		result = append(result, *status)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return statusKey(result[i].Kind, result[i].ID, result[i].ConnectivityService) <
+			statusKey(result[j].Kind, result[j].ID, result[j].ConnectivityService)
+	})
+
+	return result
+}
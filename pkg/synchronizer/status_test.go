@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSynchronizeDeviceStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher))
+
+	dgEndpoint := "http://5gcore/v1/device-group/sample-dg"
+	dgUp := false
+	mockPusher.EXPECT().PushUpdate(dgEndpoint, gomock.Any()).DoAndReturn(func(endpoint string, data []byte) error {
+		if !dgUp {
+			return &PushError{Operation: "POST", Endpoint: endpoint, StatusCode: 500, Status: "500 Internal Server Error"}
+		}
+		return nil
+	}).AnyTimes()
+	mockPusher.EXPECT().PushUpdate("http://5gcore/v1/network-slice/sample-slice", gomock.Any()).Return(nil).AnyTimes()
+	mockPusher.EXPECT().PushUpdate("http://upf/v1/config/network-slices", gomock.Any()).Return(nil).AnyTimes()
+	mockPusher.EXPECT().PushDelete(dgEndpoint).Return(nil).AnyTimes()
+
+	device := BuildSampleDevice()
+	pushErrors, err := s.SynchronizeDevice(device)
+	assert.Equal(t, 1, pushErrors)
+	assert.Nil(t, err)
+
+	assert.Equal(t, 3, len(s.GetStatus("", "")))
+
+	status := s.GetStatus(CacheModelDeviceGroup, "sample-dg")
+	assert.Equal(t, 1, len(status))
+	assert.Equal(t, "sample-cs", status[0].ConnectivityService)
+	assert.Equal(t, dgEndpoint, status[0].Endpoint)
+	assert.Equal(t, StatusPushFailed, status[0].State)
+	assert.Contains(t, status[0].LastError, "code=500")
+	assert.Equal(t, 1, status[0].Attempts)
+	assert.NotNil(t, status[0].LastAttempt)
+	assert.Nil(t, status[0].LastSuccess)
+	assert.Empty(t, status[0].LastPushedHash)
+
+	status = s.GetStatus(CacheModelSlice, "")
+	assert.Equal(t, 1, len(status))
+	assert.Equal(t, "sample-slice", status[0].ID)
+	assert.Equal(t, StatusSynchronized, status[0].State)
+	assert.NotNil(t, status[0].LastSuccess)
+	assert.NotEmpty(t, status[0].LastPushedHash)
+
+	// The core comes back, and the device-group's retry is due
+	dgUp = true
+	s.retryExpedite()
+	pushErrors, err = s.SynchronizeDevice(device)
+	assert.Equal(t, 0, pushErrors)
+	assert.Nil(t, err)
+
+	status = s.GetStatus(CacheModelDeviceGroup, "sample-dg")
+	assert.Equal(t, 1, len(status))
+	assert.Equal(t, StatusSynchronized, status[0].State)
+	assert.Empty(t, status[0].LastError)
+	assert.Equal(t, 2, status[0].Attempts)
+	assert.NotNil(t, status[0].LastSuccess)
+
+	// The slice was unchanged, so it was not pushed again
+	status = s.GetStatus(CacheModelSlice, "sample-slice")
+	assert.Equal(t, 1, status[0].Attempts)
+
+	err = s.HandleDelete(device, BuildRootPath("sample-ent", "sample-site", "dg-id", "device-group", "sample-dg"))
+	assert.Nil(t, err)
+	assert.Empty(t, s.GetStatus(CacheModelDeviceGroup, "sample-dg"))
+}
+
+// After a restart, objects that are unchanged from the persisted cache are reported as synchronized
+func TestSynchronizeDeviceStatusFromCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).Return(nil).Times(3)
+
+	s := NewSynchronizer(WithPusher(mockPusher))
+	device := BuildSampleDevice()
+	_, err := s.SynchronizeDevice(device)
+	assert.Nil(t, err)
+	want := s.GetStatus(CacheModelSlice, "sample-slice")
+
+	s2 := NewSynchronizer(WithPusher(mockPusher))
+	s2.cache = s.cache
+	_, err = s2.SynchronizeDevice(device)
+	assert.Nil(t, err)
+
+	got := s2.GetStatus(CacheModelSlice, "sample-slice")
+	assert.Equal(t, 1, len(got))
+	assert.Equal(t, StatusSynchronized, got[0].State)
+	assert.Equal(t, 0, got[0].Attempts)
+	assert.Nil(t, got[0].LastSuccess)
+	assert.Equal(t, 3, len(s2.GetStatus("", "")))
+
+	assert.NotEmpty(t, want[0].LastPushedHash)
+	assert.Equal(t, want[0].LastPushedHash, got[0].LastPushedHash)
+}
@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Strict implements a mode in which a Set is not acknowledged until it has been pushed.
+
+package synchronizer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/ygot/ygot"
+)
+
+// ObjectError is a failed push of one object
+type ObjectError struct {
+	Kind                string `json:"kind"`
+	ID                  string `json:"id"`
+	ConnectivityService string `json:"connectivity-service"`
+	Error               string `json:"error"`
+}
+
+// ApplyError is returned in strict mode when an update could not be pushed
+type ApplyError struct {
+	Errors []ObjectError
+}
+
+func (e *ApplyError) Error() string {
+	msgs := []string{}
+	for _, objErr := range e.Errors {
+		msgs = append(msgs, fmt.Sprintf("%s %s on %s: %s", objErr.Kind, objErr.ID, objErr.ConnectivityService, objErr.Error))
+	}
+	return fmt.Sprintf("%d objects failed to push: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// WithStrictMode sets whether Synchronize waits for an applied update to be pushed, and
+// returns an error if any of the pushes failed
+func WithStrictMode(strictMode bool) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.strictMode = strictMode
+	}
+}
+
+// WithStrictTimeout sets how long Synchronize waits for the pushes in strict mode
+func WithStrictTimeout(strictTimeout time.Duration) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.strictTimeout = strictTimeout
+	}
+}
+
+// synchronizeStrict queues an update and waits for the result of its first synchronization.
+// The update fails only if an object that setPath affects fails.
+func (s *Synchronizer) synchronizeStrict(config ygot.ValidatedGoStruct, callbackType gnmi.ConfigCallbackType, path *pb.Path, setPath *pb.Path) error {
+	// The caller is waiting, so objects that are backing off are pushed now
+	s.retryExpedite()
+
+	done := make(chan error, 1)
+	err := s.enqueue(config, callbackType, path, done, setPath)
+	if err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(s.strictTimeout)
+	defer timer.Stop()
+
+	select {
+	case err = <-done:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("Timed out after %s waiting for the update to be synchronized", s.strictTimeout)
+	}
+}
+
+// newObjectError returns the ObjectError of an object that failed to render or push
+func newObjectError(kind string, id string, cs string, err error) ObjectError {
+	return ObjectError{
+		Kind:                kind,
+		ID:                  id,
+		ConnectivityService: cs,
+		Error:               err.Error(),
+	}
+}
+
+// strictErrors returns the errors of one synchronization pass that belong to the Set of a
+// strict update. If the Set names what it changed, only the objects that it affects count.
+// Otherwise, objects that were already failing before the pass do not count, as the Set
+// did not break them. Returns nil if the pass did not report per-object errors.
+func (s *Synchronizer) strictErrors(update *ConfigUpdate, objErrors []ObjectError, failing map[string]bool) []ObjectError {
+	if objErrors == nil {
+		return nil
+	}
+
+	var targets syncTargets
+	if update.setPath != nil {
+		targets = s.affectedObjects(update.config.(*RootDevice), update.setPath)
+	}
+
+	result := []ObjectError{}
+	for _, objErr := range objErrors {
+		if targets != nil {
+			if (objErr.Kind == CacheModelDeviceGroup) && !targets.hasDeviceGroup(objErr.ID) {
+				continue
+			}
+			if (objErr.Kind != CacheModelDeviceGroup) && !targets.hasSlice(objErr.ID) {
+				continue
+			}
+		} else if failing[statusKey(objErr.Kind, objErr.ID, objErr.ConnectivityService)] {
+			continue
+		}
+		result = append(result, objErr)
+	}
+	return result
+}
+
+// strictResult returns the error to report to a strict mode caller, given the result of
+// one synchronization pass. An object that failed to render fails the update, just as one
+// that failed to push does. If objErrors is not nil, it is taken to be every failure that
+// belongs to the update, and pushErrors is ignored.
+func strictResult(pushErrors int, objErrors []ObjectError, err error) error {
+	if err != nil {
+		return err
+	}
+	if len(objErrors) > 0 {
+		return &ApplyError{Errors: objErrors}
+	}
+	if objErrors != nil {
+		return nil
+	}
+	if pushErrors > 0 {
+		return fmt.Errorf("%d pushes failed", pushErrors)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSynchronizeStrict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithStrictMode(true), WithRetryInterval(10*time.Millisecond))
+	s.Start()
+
+	dgUp := true
+	mockPusher.EXPECT().PushUpdate("http://5gcore/v1/device-group/sample-dg", gomock.Any()).DoAndReturn(func(endpoint string, data []byte) error {
+		if !dgUp {
+			return &PushError{Operation: "PUT", Endpoint: endpoint, StatusCode: 400, Status: "400 Bad Request"}
+		}
+		return nil
+	}).AnyTimes()
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	device := BuildSampleDevice()
+	err := s.Synchronize(device, gnmi.Apply, nil)
+	assert.Nil(t, err)
+
+	// The core rejects a change to the device-group
+	dgUp = false
+	ipd := device.Enterprises.Enterprise["sample-ent"].Site["sample-site"].IpDomain["sample-ipd"]
+	ipd.DnsPrimary = aStr("1.1.1.1")
+	err = s.Synchronize(device, gnmi.Apply, nil)
+	applyErr, okay := err.(*ApplyError)
+	assert.True(t, okay)
+	assert.Equal(t, 1, len(applyErr.Errors))
+	assert.Equal(t, CacheModelDeviceGroup, applyErr.Errors[0].Kind)
+	assert.Equal(t, "sample-dg", applyErr.Errors[0].ID)
+	assert.Equal(t, "sample-cs", applyErr.Errors[0].ConnectivityService)
+	assert.Contains(t, applyErr.Errors[0].Error, "code=400")
+
+	// A rollback does not wait
+	dgUp = true
+	err = s.Synchronize(device, gnmi.Rollback, nil)
+	assert.Nil(t, err)
+	waitForSyncIdle(t, s, 5*time.Second)
+}
+
+func TestSynchronizeStrictRenderError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithStrictMode(true))
+	s.Start()
+
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	// A slice that cannot be rendered fails the Set, although nothing failed to push
+	device := BuildSampleDevice()
+	device.Enterprises.Enterprise["sample-ent"].Application["sample-app"].Address = nil
+	err := s.Synchronize(device, gnmi.Apply, nil)
+	applyErr, okay := err.(*ApplyError)
+	assert.True(t, okay)
+	assert.Equal(t, []ObjectError{{
+		Kind:                CacheModelSlice,
+		ID:                  "sample-slice",
+		ConnectivityService: "sample-cs",
+		Error:               "Slice sample-slice Application sample-app has empty address",
+	}}, applyErr.Errors)
+	waitForSyncIdle(t, s, 5*time.Second)
+}
+
+func TestSynchronizeStrictUnrelatedFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithStrictMode(true))
+	s.Start()
+
+	dgPushes := 0
+	mockPusher.EXPECT().PushUpdate("http://5gcore/v1/device-group/sample-dg", gomock.Any()).DoAndReturn(func(endpoint string, data []byte) error {
+		dgPushes++
+		return nil
+	}).AnyTimes()
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	device := BuildSampleDevice()
+	device.Enterprises.Enterprise["sample-ent"].Application["sample-app"].Address = nil
+	err := s.Synchronize(device, gnmi.Apply, nil)
+	_, okay := err.(*ApplyError)
+	assert.True(t, okay)
+
+	// The slice still fails to render, but that is not the fault of a Set that only
+	// changes the device-group
+	ipd := device.Enterprises.Enterprise["sample-ent"].Site["sample-site"].IpDomain["sample-ipd"]
+	ipd.DnsPrimary = aStr("1.1.1.1")
+	err = s.Synchronize(device, gnmi.Apply, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, dgPushes)
+	waitForSyncIdle(t, s, 5*time.Second)
+}
+
+func TestSynchronizeStrictTimeout(t *testing.T) {
+	s := NewSynchronizer(WithStrictMode(true), WithStrictTimeout(50*time.Millisecond))
+	done := make(chan struct{})
+	s.synchronizeDeviceFunc = func(config ygot.ValidatedGoStruct) (int, error) {
+		<-done
+		return 0, nil
+	}
+	s.Start()
+
+	err := s.Synchronize(&mockConfig{}, gnmi.Apply, nil)
+	assert.EqualError(t, err, "Timed out after 50ms waiting for the update to be synchronized")
+	close(done)
+	waitForSyncIdle(t, s, 5*time.Second)
+}
@@ -11,11 +11,11 @@ import (
 	"sort"
 )
 
-// SynchronizeDeviceGroup synchronizes a device group
-func (s *Synchronizer) SynchronizeDeviceGroup(scope *AetherScope, dg *DeviceGroup) (int, error) {
+// renderDeviceGroup converts a device group into the document that is pushed to the core
+func (s *Synchronizer) renderDeviceGroup(scope *AetherScope, dg *DeviceGroup) (*deviceGroup, error) {
 	err := validateDeviceGroup(dg)
 	if err != nil {
-		return 0, fmt.Errorf("DeviceGroup %s failed validation: %v", *dg.DeviceGroupId, err)
+		return nil, fmt.Errorf("DeviceGroup %s failed validation: %v", *dg.DeviceGroupId, err)
 	}
 
 	dgCore := deviceGroup{
@@ -24,11 +24,11 @@ func (s *Synchronizer) SynchronizeDeviceGroup(scope *AetherScope, dg *DeviceGrou
 	}
 
 	if scope.Site.ImsiDefinition == nil {
-		return 0, fmt.Errorf("DeviceGroup %s site has nil ImsiDefinition", *dg.DeviceGroupId)
+		return nil, fmt.Errorf("DeviceGroup %s site has nil ImsiDefinition", *dg.DeviceGroupId)
 	}
 	err = validateImsiDefinition(scope.Site.ImsiDefinition)
 	if err != nil {
-		return 0, fmt.Errorf("DeviceGroup %s unable to determine Site.ImsiDefinition: %s", *dg.DeviceGroupId, err)
+		return nil, fmt.Errorf("DeviceGroup %s unable to determine Site.ImsiDefinition: %s", *dg.DeviceGroupId, err)
 	}
 
 	// be deterministic...
@@ -53,7 +53,7 @@ func (s *Synchronizer) SynchronizeDeviceGroup(scope *AetherScope, dg *DeviceGrou
 
 		device, err := s.GetDevice(scope, deviceID)
 		if err != nil {
-			return 0, fmt.Errorf("DeviceGroup %s failed to get Device: %s", *dg.DeviceGroupId, err)
+			return nil, fmt.Errorf("DeviceGroup %s failed to get Device: %s", *dg.DeviceGroupId, err)
 		}
 
 		if (device.SimCard == nil) || (*device.SimCard == "") {
@@ -62,24 +62,24 @@ func (s *Synchronizer) SynchronizeDeviceGroup(scope *AetherScope, dg *DeviceGrou
 
 		simCard, err := s.GetSimCard(scope, device.SimCard)
 		if err != nil {
-			return 0, fmt.Errorf("DeviceGroup %s failed to get SimCard: %s", *dg.DeviceGroupId, err)
+			return nil, fmt.Errorf("DeviceGroup %s failed to get SimCard: %s", *dg.DeviceGroupId, err)
 		}
 
 		imsi, err := FormatImsiDef(scope.Site.ImsiDefinition, *simCard.Imsi)
 		if err != nil {
-			return 0, fmt.Errorf("Failed to format IMSI in dg %s: %v", *dg.DeviceGroupId, err)
+			return nil, fmt.Errorf("Failed to format IMSI in dg %s: %v", *dg.DeviceGroupId, err)
 		}
 		dgCore.Imsis = append(dgCore.Imsis, fmt.Sprintf("%015d", imsi))
 	}
 
 	ipd, err := s.GetIPDomain(scope, dg.IpDomain)
 	if err != nil {
-		return 0, fmt.Errorf("DeviceGroup %s failed to get IpDomain: %s", *dg.DeviceGroupId, err)
+		return nil, fmt.Errorf("DeviceGroup %s failed to get IpDomain: %s", *dg.DeviceGroupId, err)
 	}
 
 	err = validateIPDomain(ipd)
 	if err != nil {
-		return 0, fmt.Errorf("DeviceGroup %s IPDomain %s is invalid: %s", *dg.DeviceGroupId, *ipd.IpDomainId, err)
+		return nil, fmt.Errorf("DeviceGroup %s IPDomain %s is invalid: %s", *dg.DeviceGroupId, *ipd.IpDomainId, err)
 	}
 
 	dgCore.IPDomainName = *ipd.IpDomainId
@@ -95,7 +95,7 @@ func (s *Synchronizer) SynchronizeDeviceGroup(scope *AetherScope, dg *DeviceGrou
 
 	rocTrafficClass, err := s.GetTrafficClass(scope, dg.TrafficClass)
 	if err != nil {
-		return 0, fmt.Errorf("DG %s unable to determine traffic class: %s", *dg.DeviceGroupId, err)
+		return nil, fmt.Errorf("DG %s unable to determine traffic class: %s", *dg.DeviceGroupId, err)
 	}
 	tcCore := &trafficClass{Name: *rocTrafficClass.TrafficClassId,
 		PDB:  DerefUint16Ptr(rocTrafficClass.Pdb, 300),
@@ -104,8 +104,19 @@ func (s *Synchronizer) SynchronizeDeviceGroup(scope *AetherScope, dg *DeviceGrou
 		ARP:  DerefUint8Ptr(rocTrafficClass.Arp, 9)}
 	dgCore.IPDomain.Qos.TrafficClass = tcCore
 
+	return &dgCore, nil
+}
+
+// SynchronizeDeviceGroup synchronizes a device group
+func (s *Synchronizer) SynchronizeDeviceGroup(scope *AetherScope, dg *DeviceGroup) (int, error) {
+	dgCore, err := s.renderDeviceGroup(scope, dg)
+	if err != nil {
+		return 0, err
+	}
+
 	if s.partialUpdateEnable && s.CacheCheck(CacheModelDeviceGroup, *dg.DeviceGroupId, dgCore) {
 		log.Infof("Core Device-Group %s has not changed", *dg.DeviceGroupId)
+		s.statusUnchanged(CacheModelDeviceGroup, *dg.DeviceGroupId, *scope.ConnectivityService.ConnectivityServiceId)
 		return 0, nil
 	}
 
@@ -119,12 +130,14 @@ func (s *Synchronizer) SynchronizeDeviceGroup(scope *AetherScope, dg *DeviceGrou
 	}
 
 	url := fmt.Sprintf("%s/v1/device-group/%s", *scope.ConnectivityService.Core_5GEndpoint, *dg.DeviceGroupId)
-	err = s.pusher.PushUpdate(url, data)
+	pushed, err := s.pushUpdate(CacheModelDeviceGroup, *dg.DeviceGroupId, *scope.ConnectivityService.ConnectivityServiceId, url, data)
 	if err != nil {
 		return 1, fmt.Errorf("DeviceGroup %s failed to Push update: %s", *dg.DeviceGroupId, err)
 	}
 
-	s.CacheUpdate(CacheModelDeviceGroup, *dg.DeviceGroupId, dgCore)
+	if pushed {
+		s.CacheUpdate(CacheModelDeviceGroup, *dg.DeviceGroupId, dgCore)
+	}
 
 	return 0, nil
 }
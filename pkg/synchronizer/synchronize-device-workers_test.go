@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+// buildTwoSiteDevice builds the sample device plus a second site that has only a device-group
+func buildTwoSiteDevice() *RootDevice {
+	device := BuildSampleDevice()
+
+	_, _, _, _, site2, dg2 := BuildSampleDeviceGroup()
+	site2.SiteId = aStr("other-site")
+	dg2.DeviceGroupId = aStr("other-dg")
+	site2.DeviceGroup = map[string]*DeviceGroup{"other-dg": dg2}
+	device.Enterprises.Enterprise["sample-ent"].Site["other-site"] = site2
+
+	return device
+}
+
+// A slow push to one site does not hold up the other site
+func TestSynchronizeDeviceParallelSites(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithWorkers(2))
+
+	var mu sync.Mutex
+	pushes := []string{}
+	otherPushed := make(chan struct{})
+
+	mockPusher.EXPECT().PushUpdate("http://5gcore/v1/device-group/sample-dg", gomock.Any()).DoAndReturn(func(endpoint string, data []byte) error {
+		select {
+		case <-otherPushed:
+		case <-time.After(5 * time.Second):
+			return errors.New("other-site was not pushed in parallel")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		pushes = append(pushes, endpoint)
+		return nil
+	})
+	mockPusher.EXPECT().PushUpdate("http://5gcore/v1/device-group/other-dg", gomock.Any()).DoAndReturn(func(endpoint string, data []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		pushes = append(pushes, endpoint)
+		close(otherPushed)
+		return nil
+	})
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).DoAndReturn(func(endpoint string, data []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		pushes = append(pushes, endpoint)
+		return nil
+	}).Times(2)
+
+	pushErrors, err := s.SynchronizeDevice(buildTwoSiteDevice())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, pushErrors)
+
+	// Within a site, device-group, then core slice, then UPF
+	assert.Equal(t, []string{
+		"http://5gcore/v1/device-group/other-dg",
+		"http://5gcore/v1/device-group/sample-dg",
+		"http://5gcore/v1/network-slice/sample-slice",
+		"http://upf/v1/config/network-slices",
+	}, pushes)
+}
+
+// A site on two connectivity services pushes its slice to the UPF once
+func TestSynchronizeDeviceSharedUpf(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithWorkers(2))
+
+	device := BuildSampleDevice()
+	cs2 := MakeCs("other-cs-desc", "other-cs-dn", "other-cs")
+	cs2.Core_5GEndpoint = aStr("http://other5gcore")
+	device.ConnectivityServices.ConnectivityService["other-cs"] = cs2
+	device.Enterprises.Enterprise["sample-ent"].ConnectivityService["other-cs"] = &EnterpriseConnectivityService{
+		ConnectivityService: aStr("other-cs"),
+		Enabled:             aBool(true),
+	}
+
+	mockPusher.EXPECT().PushUpdate("http://upf/v1/config/network-slices", gomock.Any()).Return(nil).Times(1)
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	pushErrors, err := s.SynchronizeDevice(device)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, pushErrors)
+	assert.Len(t, s.GetStatus(CacheModelSliceUpf, "sample-slice"), 2)
+}
+
+func TestSynchronizeDeviceWorkersCount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).Return(errors.New("down")).Times(3)
+
+	// Push failures from every site are added up
+	s := NewSynchronizer(WithPusher(mockPusher), WithWorkers(0))
+	assert.Equal(t, 1, s.workers)
+	pushErrors, err := s.SynchronizeDevice(buildTwoSiteDevice())
+	assert.Nil(t, err)
+	assert.Equal(t, 3, pushErrors)
+}
@@ -7,6 +7,7 @@ package synchronizer
 
 import (
 	"github.com/openconfig/ygot/ygot"
+	"sync"
 	"time"
 )
 
@@ -14,27 +15,88 @@ import (
 //   1) pushFailures -- a count of pushes that failed to the core. Synchronizer should retry again later.
 //   2) error -- a fatal error that occurred during synchronization.
 func (s *Synchronizer) SynchronizeDevice(config ygot.ValidatedGoStruct) (int, error) {
-	device := config.(*RootDevice)
+	pushFailures, _, err := s.synchronizeDevice(config.(*RootDevice), nil)
+	return pushFailures, err
+}
 
+// synchronizeDevice synchronizes the device-groups and slices of a device that are in
+// targets. A nil targets synchronizes all of them. Also returns an ObjectError for each
+// object that failed to render or push in this pass.
+func (s *Synchronizer) synchronizeDevice(device *RootDevice, targets syncTargets) (int, []ObjectError, error) {
 	pushFailures := 0
+	objErrors := []ObjectError{}
+
+	if s.dryRun {
+		s.planBegin()
+		defer s.planEnd()
+	}
 
 	if device.Enterprises == nil {
 		log.Info("No enteprises")
-		return 0, nil
+		return 0, objErrors, nil
 	}
 
 	if device.ConnectivityServices == nil {
 		log.Info("No connectivity services")
-		return 0, nil
+		return 0, objErrors, nil
 	}
 
+	// Each (connectivity service, enterprise, site) is a unit of work that is independent of
+	// the others, and may be pushed in parallel. Within a unit, device-groups are pushed
+	// before the slices that use them, and slices are pushed to the core before the UPF.
+	csStart := map[string]time.Time{}
 	for _, cs := range device.ConnectivityServices.ConnectivityService {
-		tStart := time.Now()
+		csStart[*cs.ConnectivityServiceId] = time.Now()
 		KpiSynchronizationTotal.WithLabelValues(*cs.ConnectivityServiceId).Inc()
+	}
+	units := deviceScopes(device)
+
+	var mu sync.Mutex
+	csEnd := map[string]time.Time{}
+	unitChannel := make(chan *AetherScope)
+	wg := sync.WaitGroup{}
+	for i := 0; (i < s.workers) && (i < len(units)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for scope := range unitChannel {
+				unitPushFailures, unitErrors := s.synchronizeSite(scope, targets)
+
+				mu.Lock()
+				pushFailures += unitPushFailures
+				objErrors = append(objErrors, unitErrors...)
+				csEnd[*scope.ConnectivityService.ConnectivityServiceId] = time.Now()
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, scope := range units {
+		unitChannel <- scope
+	}
+	close(unitChannel)
+	wg.Wait()
+	s.cacheFlush()
 
-		scope := &AetherScope{
-			ConnectivityService: cs,
-			RootDevice:          device}
+	for csID, tStart := range csStart {
+		tEnd, okay := csEnd[csID]
+		if !okay {
+			tEnd = time.Now()
+		}
+		KpiSynchronizationDuration.WithLabelValues(csID).Observe(tEnd.Sub(tStart).Seconds())
+	}
+
+	return pushFailures, objErrors, nil
+}
+
+// deviceScopes returns a scope for each site of each enterprise, on each connectivity
+// service that the enterprise uses
+func deviceScopes(device *RootDevice) []*AetherScope {
+	scopes := []*AetherScope{}
+	if (device.Enterprises == nil) || (device.ConnectivityServices == nil) {
+		return scopes
+	}
+
+	for _, cs := range device.ConnectivityServices.ConnectivityService {
 		for _, enterprise := range device.Enterprises.Enterprise {
 			// Does this enterprise use the current ConnectivityService?
 			// If not, skip it
@@ -48,38 +110,75 @@ func (s *Synchronizer) SynchronizeDevice(config ygot.ValidatedGoStruct) (int, er
 				continue
 			}
 
-			scope.Enterprise = enterprise
 			for _, site := range enterprise.Site {
-				scope.Site = site
-				for _, dg := range site.DeviceGroup {
-					dgPushErrors, err := s.SynchronizeDeviceGroup(scope, dg)
-					if err != nil {
-						log.Warnf("DG %s failed to synchronize Core: %s", *dg.DeviceGroupId, err)
-					}
-					pushFailures += dgPushErrors
-				}
-			sliceLoop:
-				for _, slice := range site.Slice {
-					slicePushFailures, err := s.SynchronizeSlice(scope, slice)
-					pushFailures += slicePushFailures
-					if err != nil {
-						log.Warnf("VCS %s failed to synchronize Core: %s", *slice.SliceId, err)
-						// Do not try to synchronize the UPF, if we've already failed
-						continue sliceLoop
-					}
-
-					upfPushFailures, err := s.SynchronizeSliceUPF(scope, slice)
-					pushFailures += upfPushFailures
-					if err != nil {
-						log.Warnf("Slice %s failed to synchronize UPF: %s", *slice.SliceId, err)
-						continue sliceLoop
-					}
-				}
+				scopes = append(scopes, &AetherScope{
+					RootDevice:          device,
+					ConnectivityService: cs,
+					Enterprise:          enterprise,
+					Site:                site})
 			}
 		}
+	}
 
-		KpiSynchronizationDuration.WithLabelValues(*cs.ConnectivityServiceId).Observe(time.Since(tStart).Seconds())
+	return scopes
+}
+
+// synchronizeSite synchronizes the device-groups and slices of one site that are in targets
+// to one connectivity service, returning the number of pushes that failed, and the error of
+// each object that failed to render or push.
+func (s *Synchronizer) synchronizeSite(scope *AetherScope, targets syncTargets) (int, []ObjectError) {
+	pushFailures := 0
+	objErrors := []ObjectError{}
+	csID := *scope.ConnectivityService.ConnectivityServiceId
+
+	for _, dg := range scope.Site.DeviceGroup {
+		if !targets.hasDeviceGroup(*dg.DeviceGroupId) {
+			continue
+		}
+		dgPushErrors, err := s.SynchronizeDeviceGroup(scope, dg)
+		if err != nil {
+			log.Warnf("DG %s failed to synchronize Core: %s", *dg.DeviceGroupId, err)
+			objErrors = append(objErrors, newObjectError(CacheModelDeviceGroup, *dg.DeviceGroupId, csID, err))
+			if dgPushErrors == 0 {
+				s.statusError(CacheModelDeviceGroup, *dg.DeviceGroupId, csID, err)
+			}
+		}
+		pushFailures += dgPushErrors
+	}
+sliceLoop:
+	for _, slice := range scope.Site.Slice {
+		if !targets.hasSlice(*slice.SliceId) {
+			continue
+		}
+		slicePushFailures, err := s.SynchronizeSlice(scope, slice)
+		pushFailures += slicePushFailures
+		if isFQDNPending(err) {
+			// Like a queued retry, this is not a failure; the FQDN loop synchronizes the
+			// slice again once the host name is resolved
+			log.Infof("VCS %s is waiting for a host name to be resolved: %s", *slice.SliceId, err)
+			continue sliceLoop
+		}
+		if err != nil {
+			log.Warnf("VCS %s failed to synchronize Core: %s", *slice.SliceId, err)
+			objErrors = append(objErrors, newObjectError(CacheModelSlice, *slice.SliceId, csID, err))
+			if slicePushFailures == 0 {
+				s.statusError(CacheModelSlice, *slice.SliceId, csID, err)
+			}
+			// Do not try to synchronize the UPF, if we've already failed
+			continue sliceLoop
+		}
+
+		upfPushFailures, err := s.SynchronizeSliceUPF(scope, slice)
+		pushFailures += upfPushFailures
+		if err != nil {
+			log.Warnf("Slice %s failed to synchronize UPF: %s", *slice.SliceId, err)
+			objErrors = append(objErrors, newObjectError(CacheModelSliceUpf, *slice.SliceId, csID, err))
+			if upfPushFailures == 0 {
+				s.statusError(CacheModelSliceUpf, *slice.SliceId, csID, err)
+			}
+			continue sliceLoop
+		}
 	}
 
-	return pushFailures, nil
+	return pushFailures, objErrors
 }
@@ -10,32 +10,32 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
-	"strings"
 )
 
 func (s *Synchronizer) mapPriority(i uint8) uint8 {
 	return i // // At one point priority was flipped but this was incorrect
 }
 
-// SynchronizeSlice synchronizes the VCSes
-// Return a count of push-related errors
-func (s *Synchronizer) SynchronizeSlice(scope *AetherScope, slice *Slice) (int, error) {
+// renderSlice converts a slice into the document that is pushed to the core
+func (s *Synchronizer) renderSlice(scope *AetherScope, slice *Slice) (*coreSlice, error) {
+	slice, _ = s.applyTemplate(scope, slice)
+
 	dgList, err := s.GetSliceDG(scope, slice)
 	if err != nil {
-		return 0, fmt.Errorf("Slice %s unable to determine site: %s", *slice.SliceId, err)
+		return nil, fmt.Errorf("Slice %s unable to determine site: %s", *slice.SliceId, err)
 	}
 
 	err = validateSlice(slice)
 	if err != nil {
-		return 0, fmt.Errorf("Slice %s is invalid: %s", *slice.SliceId, err)
+		return nil, fmt.Errorf("Slice %s is invalid: %s", *slice.SliceId, err)
 	}
 
 	if scope.Site.ImsiDefinition == nil {
-		return 0, fmt.Errorf("Slice %s Site %s has nil Site.ImsiDefinition", *slice.SliceId, *scope.Site.SiteId)
+		return nil, fmt.Errorf("Slice %s Site %s has nil Site.ImsiDefinition", *slice.SliceId, *scope.Site.SiteId)
 	}
 	err = validateImsiDefinition(scope.Site.ImsiDefinition)
 	if err != nil {
-		return 0, fmt.Errorf("Slice %s unable to determine Site.ImsiDefinition: %s", *slice.SliceId, err)
+		return nil, fmt.Errorf("Slice %s unable to determine Site.ImsiDefinition: %s", *slice.SliceId, err)
 	}
 	plmn := plmn{
 		Mcc: *scope.Site.ImsiDefinition.Mcc,
@@ -58,12 +58,12 @@ func (s *Synchronizer) SynchronizeSlice(scope *AetherScope, slice *Slice) (int,
 			ap := scope.Site.SmallCell[k]
 			err = validateSmallCell(ap)
 			if err != nil {
-				return 0, fmt.Errorf("SmallCell invalid: %s", err)
+				return nil, fmt.Errorf("SmallCell invalid: %s", err)
 			}
 			if *ap.Enable {
 				tac, err := strconv.ParseUint(*ap.Tac, 16, 32)
 				if err != nil {
-					return 0, fmt.Errorf("SmallCell Failed to convert tac %s to integer: %v", *ap.Tac, err)
+					return nil, fmt.Errorf("SmallCell Failed to convert tac %s to integer: %v", *ap.Tac, err)
 				}
 				gNodeB := gNodeB{
 					Name: *ap.Address,
@@ -75,13 +75,13 @@ func (s *Synchronizer) SynchronizeSlice(scope *AetherScope, slice *Slice) (int,
 	}
 
 	if slice.Upf != nil {
-		aUpf, err := s.GetUpf(scope, slice.Upf)
+		aUpf, err := s.activeUpf(scope, slice)
 		if err != nil {
-			return 0, fmt.Errorf("Slice %s unable to determine upf: %s", *slice.SliceId, err)
+			return nil, fmt.Errorf("Slice %s unable to determine upf: %s", *slice.SliceId, err)
 		}
 		err = validateUpf(aUpf)
 		if err != nil {
-			return 0, fmt.Errorf("Slice %s Upf is invalid: %s", *slice.SliceId, err)
+			return nil, fmt.Errorf("Slice %s Upf is invalid: %s", *slice.SliceId, err)
 		}
 		siteInfo.Upf = upf{
 			Name: *aUpf.Address,
@@ -109,6 +109,13 @@ func (s *Synchronizer) SynchronizeSlice(scope *AetherScope, slice *Slice) (int,
 		coreSlice.DeviceGroup = append(coreSlice.DeviceGroup, *dg.DeviceGroupId)
 	}
 
+	// The address families of the slice's UE pools. Default-behavior rules and resolved host
+	// names of other families are left out.
+	families := s.sliceFamilies(scope, dgList)
+
+	// The application, or default-behavior, that each rule comes from
+	sources := []string{}
+
 	// be deterministic...
 	appKeys := []string{}
 	for k := range slice.Filter {
@@ -120,12 +127,18 @@ func (s *Synchronizer) SynchronizeSlice(scope *AetherScope, slice *Slice) (int,
 		appRef := slice.Filter[k]
 		app, err := s.GetApplication(scope, appRef.Application)
 		if err != nil {
-			return 0, fmt.Errorf("Slice %s unable to determine application: %s", *slice.SliceId, err)
+			return nil, fmt.Errorf("Slice %s unable to determine application: %s", *slice.SliceId, err)
 		}
 
-		if (app.Address == nil) || (*app.Address == "") {
+		if (app.Address == nil) || (len(splitAddresses(*app.Address)) == 0) {
 			// this is a temporary restriction
-			return 0, fmt.Errorf("Slice %s Application %s has empty address", *slice.SliceId, *app.ApplicationId)
+			return nil, fmt.Errorf("Slice %s Application %s has empty address", *slice.SliceId, *app.ApplicationId)
+		}
+
+		// One rule is rendered for each prefix of each endpoint
+		prefixes, err := s.applicationPrefixes(app, families)
+		if err != nil {
+			return nil, fmt.Errorf("Slice %s Application %s has invalid address: %w", *slice.SliceId, *app.ApplicationId, err)
 		}
 
 		// be deterministic...
@@ -141,12 +154,6 @@ func (s *Synchronizer) SynchronizeSlice(scope *AetherScope, slice *Slice) (int,
 				Name: fmt.Sprintf("%s-%s", *app.ApplicationId, epName),
 			}
 
-			if strings.Contains(*app.Address, "/") {
-				appCore.Endpoint = *app.Address
-			} else {
-				appCore.Endpoint = *app.Address + "/32"
-			}
-
 			if endpoint.PortStart != nil {
 				appCore.DestPortStart = endpoint.PortStart
 				if endpoint.PortEnd != nil {
@@ -158,11 +165,10 @@ func (s *Synchronizer) SynchronizeSlice(scope *AetherScope, slice *Slice) (int,
 			}
 
 			if endpoint.Protocol != nil {
-				protoNum, err := ProtoStringToProtoNumber(*endpoint.Protocol)
+				appCore.Protocol, err = parseProtocol(*endpoint.Protocol, endpoint.PortStart != nil)
 				if err != nil {
-					return 0, fmt.Errorf("Slice %s Application %s unable to determine protocol: %s", *slice.SliceId, *app.ApplicationId, err)
+					return nil, fmt.Errorf("Slice %s Application %s unable to determine protocol: %s", *slice.SliceId, *app.ApplicationId, err)
 				}
-				appCore.Protocol = &protoNum
 			}
 
 			if (appRef.Allow != nil) && (*appRef.Allow) {
@@ -190,7 +196,7 @@ func (s *Synchronizer) SynchronizeSlice(scope *AetherScope, slice *Slice) (int,
 			if endpoint.TrafficClass != nil {
 				rocTrafficClass, err := s.GetTrafficClass(scope, endpoint.TrafficClass)
 				if err != nil {
-					return 0, fmt.Errorf("Slice %s application %s unable to determine traffic class: %s", *slice.SliceId, *app.ApplicationId, err)
+					return nil, fmt.Errorf("Slice %s application %s unable to determine traffic class: %s", *slice.SliceId, *app.ApplicationId, err)
 				}
 				tcCore := &trafficClass{Name: *rocTrafficClass.TrafficClassId,
 					PDB:  DerefUint16Ptr(rocTrafficClass.Pdb, 300),
@@ -201,32 +207,57 @@ func (s *Synchronizer) SynchronizeSlice(scope *AetherScope, slice *Slice) (int,
 			}
 
 			appCore.Priority = s.mapPriority(DerefUint8Ptr(appRef.Priority, 0))
-			coreSlice.ApplicationFilteringRules = append(coreSlice.ApplicationFilteringRules, appCore)
+
+			// The rules of an application with more than one prefix are numbered
+			for i, prefix := range prefixes {
+				rule := appCore
+				rule.Endpoint = prefix
+				if len(prefixes) > 1 {
+					rule.Name = fmt.Sprintf("%s-%d", appCore.Name, i+1)
+				}
+				coreSlice.ApplicationFilteringRules = append(coreSlice.ApplicationFilteringRules, rule)
+				sources = append(sources, *app.ApplicationId)
+			}
 		}
 	}
 
-	switch *slice.DefaultBehavior {
-	case "ALLOW-ALL":
-		allowAll := appFilterRule{Name: "ALLOW-ALL", Action: "permit", Priority: s.mapPriority(250), Endpoint: "0.0.0.0/0"}
-		coreSlice.ApplicationFilteringRules = append(coreSlice.ApplicationFilteringRules, allowAll)
-	case "DENY-ALL":
-		denyAll := appFilterRule{Name: "DENY-ALL", Action: "deny", Priority: s.mapPriority(250), Endpoint: "0.0.0.0/0"}
-		coreSlice.ApplicationFilteringRules = append(coreSlice.ApplicationFilteringRules, denyAll)
-	case "ALLOW-PUBLIC":
-		denyClassA := appFilterRule{Name: "DENY-CLASS-A", Action: "deny", Priority: s.mapPriority(250), Endpoint: "10.0.0.0/8"}
-		denyClassB := appFilterRule{Name: "DENY-CLASS-B", Action: "deny", Priority: s.mapPriority(251), Endpoint: "172.16.0.0/12"}
-		denyClassC := appFilterRule{Name: "DENY-CLASS-C", Action: "deny", Priority: s.mapPriority(252), Endpoint: "192.168.0.0/16"}
-		allowAll := appFilterRule{Name: "ALLOW-ALL", Action: "permit", Priority: s.mapPriority(253), Endpoint: "0.0.0.0/0"}
-		coreSlice.ApplicationFilteringRules = append(coreSlice.ApplicationFilteringRules, denyClassA)
-		coreSlice.ApplicationFilteringRules = append(coreSlice.ApplicationFilteringRules, denyClassB)
-		coreSlice.ApplicationFilteringRules = append(coreSlice.ApplicationFilteringRules, denyClassC)
-		coreSlice.ApplicationFilteringRules = append(coreSlice.ApplicationFilteringRules, allowAll)
-	default:
-		return 0, fmt.Errorf("Slice %s has invalid defauilt-behavior %s", *slice.SliceId, *slice.DefaultBehavior)
+	policy, okay := s.filterPolicies[*slice.DefaultBehavior]
+	if !okay {
+		return nil, fmt.Errorf("Slice %s has invalid defauilt-behavior %s", *slice.SliceId, *slice.DefaultBehavior)
+	}
+	appRuleCount := len(coreSlice.ApplicationFilteringRules)
+	for i := range policy.Rules {
+		if !families[policy.Rules[i].family()] {
+			continue
+		}
+		coreSlice.ApplicationFilteringRules = append(coreSlice.ApplicationFilteringRules, policy.Rules[i].render(s))
+		sources = append(sources, policy.Name)
+	}
+
+	rules, conflicts, err := s.compileRules(coreSlice.ApplicationFilteringRules, sources, appRuleCount)
+	s.statusRuleConflicts(CacheModelSlice, *slice.SliceId, *scope.ConnectivityService.ConnectivityServiceId, conflicts)
+	if err != nil {
+		return nil, fmt.Errorf("Slice %s %v", *slice.SliceId, err)
+	}
+	for _, conflict := range conflicts {
+		log.Warnf("Slice %s: %s", *slice.SliceId, conflict.Message)
+	}
+	coreSlice.ApplicationFilteringRules = rules
+
+	return &coreSlice, nil
+}
+
+// SynchronizeSlice synchronizes the VCSes
+// Return a count of push-related errors
+func (s *Synchronizer) SynchronizeSlice(scope *AetherScope, slice *Slice) (int, error) {
+	coreSlice, err := s.renderSlice(scope, slice)
+	if err != nil {
+		return 0, err
 	}
 
 	if s.partialUpdateEnable && s.CacheCheck(CacheModelSlice, *slice.SliceId, coreSlice) {
 		log.Infof("Core Slice %s has not changed", *slice.SliceId)
+		s.statusUnchanged(CacheModelSlice, *slice.SliceId, *scope.ConnectivityService.ConnectivityServiceId)
 		return 0, nil
 	}
 
@@ -240,12 +271,14 @@ func (s *Synchronizer) SynchronizeSlice(scope *AetherScope, slice *Slice) (int,
 	}
 
 	url := fmt.Sprintf("%s/v1/network-slice/%s", *scope.ConnectivityService.Core_5GEndpoint, *slice.SliceId)
-	err = s.pusher.PushUpdate(url, data)
+	pushed, err := s.pushUpdate(CacheModelSlice, *slice.SliceId, *scope.ConnectivityService.ConnectivityServiceId, url, data)
 	if err != nil {
 		return 1, fmt.Errorf("Slice %s failed to push update: %s", *slice.SliceId, err)
 	}
 
-	s.CacheUpdate(CacheModelSlice, *slice.SliceId, coreSlice)
+	if pushed {
+		s.CacheUpdate(CacheModelSlice, *slice.SliceId, coreSlice)
+	}
 
 	return 0, nil
 }
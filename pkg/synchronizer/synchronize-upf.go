@@ -8,6 +8,7 @@ package synchronizer
 import (
 	"encoding/json"
 	"fmt"
+	"sync"
 )
 
 type sliceQos struct {
@@ -35,6 +36,7 @@ func (s *Synchronizer) SynchronizeSliceUPF(scope *AetherScope, slice *Slice) (in
 	if slice.Upf == nil {
 		return 0, fmt.Errorf("Slice %s has no UPFs to synchronize", *slice.SliceId)
 	}
+	slice, _ = s.applyTemplate(scope, slice)
 
 	aUpf, err := s.GetUpf(scope, slice.Upf)
 	if err != nil {
@@ -93,23 +95,78 @@ func (s *Synchronizer) SynchronizeSliceUPF(scope *AetherScope, slice *Slice) (in
 		}
 	}
 
-	if s.partialUpdateEnable && s.CacheCheck(CacheModelSliceUpf, *slice.SliceId, sc) {
-		log.Infof("UPF Slice %s has not changed", *slice.SliceId)
-		return 0, nil
+	active, err := s.activeUpf(scope, slice)
+	if err != nil {
+		return 0, fmt.Errorf("Slice %s unable to determine active upf: %s", *slice.SliceId, err)
+	}
+
+	// The slice is pushed to its standby UPFs too, so that they are ready to take over.
+	// Only a failure to push to the active UPF fails the slice.
+	pushFailures := 0
+	var pushErr error
+	for i, upfID := range s.upfCandidateIDs(scope, slice) {
+		candidate, okay := scope.Site.Upf[upfID]
+		if !okay || (validateUpf(candidate) != nil) || (candidate.ConfigEndpoint == nil) {
+			continue
+		}
+		err = s.pushSliceUPF(scope, slice, candidate, upfSliceID(*slice.SliceId, upfID, i == 0), sc)
+		if err == nil {
+			continue
+		}
+		if candidate != active {
+			log.Warnf("Slice %s failed to push to standby UPF %s: %s", *slice.SliceId, upfID, err)
+			continue
+		}
+		pushFailures++
+		pushErr = err
+	}
+
+	return pushFailures, pushErr
+}
+
+// upfPushLock returns the lock that serializes the pushes of the slice-upf id. A site is
+// synchronized once for each of its connectivity services, in parallel, and each of them
+// pushes the site's slices to the same UPFs.
+func (s *Synchronizer) upfPushLock(id string) *sync.Mutex {
+	s.upfPushLockMutex.Lock()
+	defer s.upfPushLockMutex.Unlock()
+
+	lock, okay := s.upfPushLocks[id]
+	if !okay {
+		lock = &sync.Mutex{}
+		s.upfPushLocks[id] = lock
+	}
+	return lock
+}
+
+// pushSliceUPF pushes the slice config sc to the config endpoint of one UPF, caching it
+// under id. Only one push of id runs at a time, so that a second push of the same config
+// finds it in the cache.
+func (s *Synchronizer) pushSliceUPF(scope *AetherScope, slice *Slice, aUpf *Upf, id string, sc *upfSliceConfig) error {
+	lock := s.upfPushLock(id)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if s.partialUpdateEnable && s.CacheCheck(CacheModelSliceUpf, id, sc) {
+		log.Infof("UPF Slice %s has not changed", id)
+		s.statusUnchanged(CacheModelSliceUpf, id, *scope.ConnectivityService.ConnectivityServiceId)
+		return nil
 	}
 
 	data, err := json.MarshalIndent(sc, "", "  ")
 	if err != nil {
-		return 0, fmt.Errorf("Slice %s failed to marshal UPF JSON: %s", *slice.SliceId, err)
+		return fmt.Errorf("Slice %s failed to marshal UPF JSON: %s", *slice.SliceId, err)
 	}
 
 	url := fmt.Sprintf("%s/v1/config/network-slices", *aUpf.ConfigEndpoint)
-	err = s.pusher.PushUpdate(url, data)
+	pushed, err := s.pushUpdate(CacheModelSliceUpf, id, *scope.ConnectivityService.ConnectivityServiceId, url, data)
 	if err != nil {
-		return 1, fmt.Errorf("slice %s failed to push UPF JSON: %s", *slice.SliceId, err)
+		return fmt.Errorf("slice %s failed to push UPF JSON: %s", *slice.SliceId, err)
 	}
 
-	s.CacheUpdate(CacheModelSliceUpf, *slice.SliceId, sc)
+	if pushed {
+		s.CacheUpdate(CacheModelSliceUpf, id, sc)
+	}
 
-	return 0, nil
+	return nil
 }
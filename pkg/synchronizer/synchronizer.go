@@ -12,6 +12,7 @@ import (
 	pb "github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/ygot/ygot"
 	"reflect"
+	"sync"
 	"time"
 )
 
@@ -26,39 +27,117 @@ func (s *Synchronizer) Synchronize(config ygot.ValidatedGoStruct, callbackType g
 
 	if callbackType == gnmi.Forced {
 		s.CacheInvalidate() // invalidate the post cache if this resync was forced by Diagnostic API
+		s.retryExpedite()   // and push objects that are backing off now, rather than later
 	}
 
-	err = s.enqueue(config, callbackType)
+	// Only an applied Set names what changed; anything else synchronizes everything
+	setPath := path
+	if (callbackType != gnmi.Apply) || !s.targetedSyncEnable {
+		path = nil
+	}
+
+	if s.strictMode && (callbackType == gnmi.Apply) {
+		return s.synchronizeStrict(config, callbackType, path, setPath)
+	}
+
+	err = s.enqueue(config, callbackType, path, nil, nil)
 	return err
 }
 
-// SynchronizeAndRetry automatically retries if synchronization fails
+// SynchronizeAndRetry synchronizes an update. A push that fails is queued, and retried
+// by the retry loop on its own exponential backoff, so that later updates cannot starve
+// it. Only a failure that was not queued is retried here, by synchronizing the whole
+// update again, until a newer update arrives and supersedes it.
 func (s *Synchronizer) SynchronizeAndRetry(update *ConfigUpdate) {
-	for {
-		// If something new has come along, then don't bother with the one we're working on
+	// If something new has come along, then don't bother with the one we're working on.
+	// A strict update is always synchronized, as its caller is waiting for the result.
+	if (update.done == nil) && s.newUpdatesPending() {
+		log.Infof("Current synchronizer update has been obsoleted")
+		s.supersede(update)
+		return
+	}
+
+	// Retries of objects that have since been removed are no longer wanted
+	s.retryPrune(update.config)
+	select {
+	case <-s.updateWakeChannel:
+	default:
+	}
+
+	var failing map[string]bool
+	if update.done != nil {
+		failing = s.statusFailing()
+	}
+
+	pushErrors, objErrors, err := s.synchronizeUpdate(update)
+
+	// In strict mode, the update is rejected if the first pass fails. The rollback that
+	// follows will supersede it, so there is no point retrying it.
+	if update.done != nil {
+		strictErr := strictResult(pushErrors, s.strictErrors(update, objErrors, failing), err)
+		update.complete(strictErr)
+		if strictErr != nil {
+			log.Warnf("Strict synchronization failed: %v", strictErr)
+			return
+		}
+	}
+
+	if err != nil {
+		log.Errorf("Synchronization error: %v", err)
+		return
+	}
+
+	passAttempts := 0
+	for (pushErrors > 0) && (s.retryPending() == 0) {
 		if s.newUpdatesPending() {
 			log.Infof("Current synchronizer update has been obsoleted")
+			s.supersede(update)
 			return
 		}
 
-		pushErrors, err := s.synchronizeDeviceFunc(update.config)
+		// Something failed that we don't know how to retry by itself
+		passAttempts++
+		delay := s.retryBackoff(passAttempts)
+		log.Infof("Synchronization encountered %d push errors, scheduling full retry in %s", pushErrors, delay)
+		if !s.updateSleep(delay) {
+			continue
+		}
+
+		pushErrors, _, err = s.synchronizeUpdate(update)
 		if err != nil {
 			log.Errorf("Synchronization error: %v", err)
 			return
 		}
+	}
 
-		if pushErrors == 0 {
-			log.Infof("Synchronization success")
-			return
-		}
+	if pushErrors > 0 {
+		log.Infof("Synchronization has %d failed pushes, left to the retry queue", pushErrors)
+		return
+	}
+	log.Infof("Synchronization success")
+}
 
-		log.Infof("Synchronization encountered %d push errors, scheduling retry", pushErrors)
+// synchronizeUpdate synchronizes the objects affected by an update, or everything if the
+// update does not say what it changed. Also returns the error of each object that failed
+// in this pass.
+func (s *Synchronizer) synchronizeUpdate(update *ConfigUpdate) (int, []ObjectError, error) {
+	s.passMutex.Lock()
+	defer s.passMutex.Unlock()
+
+	if s.synchronizeDeviceFunc != nil {
+		pushErrors, err := s.synchronizeDeviceFunc(update.config)
+		return pushErrors, nil, err
+	}
 
-		// We failed to push something to the core. Sleep before trying again.
-		// Implements a fixed interval for now; We can go exponential should it prove to
-		// be a problem.
-		time.Sleep(s.retryInterval)
+	device := update.config.(*RootDevice)
+	var targets syncTargets
+	if update.path != nil {
+		targets = s.affectedObjects(device, update.path)
+		if targets != nil {
+			log.Infof("Targeted synchronization of %s, %d objects", gnmi.PathToString(update.path), len(targets))
+		}
 	}
+	return s.synchronizeDevice(device, targets)
 }
 
 // Loop runs an infitite loop servicing synchronization requests.
@@ -90,15 +169,40 @@ func (s *Synchronizer) GetModels() *gnmi.Model {
 
 // Start the synchronizer by launching the synchronizer loop inside a thread.
 func (s *Synchronizer) Start() {
-	log.Infof("Synchronizer starting (outputFileName=%s, postEnable=%v, postTimeout=%d, retryInterval=%s, partialUpdateEnable=%v)",
+	log.Infof("Synchronizer starting (outputFileName=%s, postEnable=%v, postTimeout=%d, retryInterval=%s, retryMaxInterval=%s, partialUpdateEnable=%v, workers=%d, strictMode=%v, targetedSyncEnable=%v)",
 		s.outputFileName,
 		s.postEnable,
 		s.postTimeout,
 		s.retryInterval,
-		s.partialUpdateEnable)
+		s.retryMaxInterval,
+		s.partialUpdateEnable,
+		s.workers,
+		s.strictMode,
+		s.targetedSyncEnable)
+
+	// Restore what we pushed before a restart, so we don't push it again
+	err := s.CacheLoad()
+	if err != nil {
+		log.Warnf("Failed to load cache, starting with an empty cache: %v", err)
+	}
 
 	// TODO: Eventually we'll create a thread here that waits for config changes
 	go s.Loop()
+	go s.retryLoop()
+
+	if s.reconcileInterval > 0 {
+		go s.reconcileLoop()
+	}
+
+	if s.driftInterval > 0 {
+		go s.driftLoop()
+	}
+
+	if (s.upfFailoverWindow > 0) && (s.upfProbeInterval > 0) {
+		go s.upfProbeLoop()
+	}
+
+	go s.fqdnLoop()
 }
 
 // WithPostEnable sets the postEnable option
@@ -136,25 +240,83 @@ func WithPusher(pusher PusherInterface) SynchronizerOption {
 	}
 }
 
+// WithWorkers sets the number of sites that are synchronized in parallel
+func WithWorkers(workers int) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.workers = workers
+	}
+}
+
+// WithCacheStore sets the backend used to persist the cache
+func WithCacheStore(cacheStore CacheStore) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.cacheStore = cacheStore
+	}
+}
+
 // NewSynchronizer creates a new Synchronizer
 func NewSynchronizer(opts ...SynchronizerOption) *Synchronizer {
-	// By default, push via REST. Test infrastructure can override this.
-	p := &RESTPusher{}
-
 	s := &Synchronizer{
-		pusher:              p,
 		postEnable:          true,
 		partialUpdateEnable: DefaultPartialUpdateEnable,
 		postTimeout:         DefaultPostTimeout,
 		updateChannel:       make(chan *ConfigUpdate, 1),
-		retryInterval:       5 * time.Second,
-		cache:               map[string]interface{}{},
+		updateWakeChannel:   make(chan struct{}, 1),
+		retryInterval:       DefaultRetryInterval,
+		retryMaxInterval:    DefaultRetryMaxInterval,
+		workers:             DefaultWorkers,
+		strictTimeout:       DefaultStrictTimeout,
+		targetedSyncEnable:  DefaultTargetedSyncEnable,
+		reconcileSafeMode:   DefaultReconcileSafeMode,
+		upfProbeInterval:    DefaultUpfProbeInterval,
+		fqdnRefreshInterval: DefaultFQDNRefreshInterval,
+		filterPolicies:      BuiltinFilterPolicies(),
+		rulePriorityMode:    DefaultRulePriorityMode,
+		upfDownSince:        map[string]time.Time{},
+		upfPushLocks:        map[string]*sync.Mutex{},
+		fqdnAnswers:         map[string][]string{},
+		fqdnMisses:          map[string]map[string]int{},
+		fqdnErrors:          map[string]error{},
+		fqdnPending:         map[string]bool{},
+		fqdnWakeChannel:     make(chan struct{}, 1),
+		cache:               map[string][]byte{},
+		status:              map[string]*ObjectStatus{},
+		retryQueue:          map[string]*retryItem{},
+		wakeChannel:         make(chan struct{}, 1),
 	}
 
 	for _, opt := range opts {
 		opt(s)
 	}
 
-	s.synchronizeDeviceFunc = s.SynchronizeDevice
+	if s.workers < 1 {
+		s.workers = 1
+	}
+
+	// By default, push via REST. Test infrastructure can override this.
+	if s.pusher == nil {
+		p, err := NewRESTPusher(WithPushTimeout(s.postTimeout))
+		if err != nil {
+			// Without TLS or auth options, this cannot happen
+			log.Fatalf("Failed to create REST pusher: %v", err)
+		}
+		s.pusher = p
+	}
+
+	// Read back from the core using the pusher, if it knows how
+	if s.fetcher == nil {
+		if fetcher, okay := s.pusher.(FetcherInterface); okay {
+			s.fetcher = fetcher
+		}
+	}
+
+	if s.resolver == nil {
+		s.resolver = NewDNSResolver(DefaultResolveTimeout)
+	}
+
+	if s.outputFileName != "" {
+		s.outputSink = NewOutputSink(s.outputFileName)
+	}
+
 	return s
 }
@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Targets implements targeted synchronization, which renders only the device-groups and
+// slices that a change can affect, as found from the reference graph.
+
+package synchronizer
+
+import (
+	"github.com/onosproject/sdcore-adapter/pkg/refgraph"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// syncTargets is the set of device-groups and slices affected by a change, keyed by cacheKey
+type syncTargets map[string]bool
+
+func (t syncTargets) addDeviceGroup(id string) {
+	t[cacheKey(CacheModelDeviceGroup, id)] = true
+}
+
+func (t syncTargets) addSlice(id string) {
+	t[cacheKey(CacheModelSlice, id)] = true
+}
+
+// hasDeviceGroup returns true if the device-group is targeted. A nil syncTargets targets everything.
+func (t syncTargets) hasDeviceGroup(id string) bool {
+	return (t == nil) || t[cacheKey(CacheModelDeviceGroup, id)]
+}
+
+// hasSlice returns true if the slice is targeted. A nil syncTargets targets everything.
+func (t syncTargets) hasSlice(id string) bool {
+	return (t == nil) || t[cacheKey(CacheModelSlice, id)]
+}
+
+// WithTargetedSyncEnable sets whether an update that names the path it changed synchronizes
+// only the objects under that path
+func WithTargetedSyncEnable(targetedSyncEnable bool) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.targetedSyncEnable = targetedSyncEnable
+	}
+}
+
+// pathKey returns the value of the key of a list element
+func pathKey(elem *pb.PathElem) (string, bool) {
+	for _, v := range elem.Key {
+		return v, true
+	}
+	return "", false
+}
+
+// pathNode returns the node of the reference graph that a path is in. The path is taken to
+// be in the innermost object that the graph knows about. It returns false if the path is
+// not in an enterprise.
+func pathNode(path *pb.Path) (refgraph.NodeID, bool) {
+	elems := path.GetElem()
+	if (len(elems) < 2) || (elems[0].Name != "enterprises") || (elems[1].Name != "enterprise") {
+		// Connectivity services, or the root
+		return refgraph.NodeID{}, false
+	}
+
+	entID, hasKey := pathKey(elems[1])
+	if !hasKey {
+		return refgraph.NodeID{}, false
+	}
+	node := refgraph.NodeID{Kind: refgraph.KindEnterprise, ID: entID}
+
+	if len(elems) == 2 {
+		return node, true
+	}
+	id, hasKey := pathKey(elems[2])
+	if !hasKey {
+		return node, true
+	}
+	child, err := refgraph.NewNodeID(refgraph.NodeKind(elems[2].Name), entID, "", id)
+	if (err != nil) || (child.Enterprise == "") {
+		// A leaf of the enterprise, or something the graph does not know
+		return node, true
+	}
+	node = child
+
+	if (node.Kind != refgraph.KindSite) || (len(elems) == 3) {
+		return node, true
+	}
+	id, hasKey = pathKey(elems[3])
+	if !hasKey {
+		return node, true
+	}
+	child, err = refgraph.NewNodeID(refgraph.NodeKind(elems[3].Name), entID, node.ID, id)
+	if (err != nil) || (child.Site == "") {
+		// Something in the site that is not pushed by itself, such as the imsi-definition
+		return node, true
+	}
+	return child, true
+}
+
+// affectedObjects returns the device-groups and slices that a change at path may affect. It
+// returns nil if the change may affect anything.
+func (s *Synchronizer) affectedObjects(device *RootDevice, path *pb.Path) syncTargets {
+	node, okay := pathNode(path)
+	if !okay {
+		return nil
+	}
+
+	targets := syncTargets{}
+	graph := s.buildGraph(device)
+	if !graph.Has(node) {
+		// Nothing left to push; deletes are handled separately
+		return targets
+	}
+
+	for _, obj := range graph.Impact(node).Southbound {
+		switch obj.Kind {
+		case refgraph.KindDeviceGroup:
+			targets.addDeviceGroup(obj.ID)
+		case refgraph.KindSlice:
+			targets.addSlice(obj.ID)
+		}
+	}
+
+	return targets
+}
@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"github.com/golang/mock/gomock"
+	"github.com/onosproject/sdcore-adapter/pkg/gnmi"
+	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// buildTargetsDevice builds the sample device, with a second device-group that has no
+// devices and that no slice uses
+func buildTargetsDevice(t *testing.T) *RootDevice {
+	device := BuildSampleDevice()
+	site := device.Enterprises.Enterprise["sample-ent"].Site["sample-site"]
+
+	dgCopy, err := ygot.DeepCopy(site.DeviceGroup["sample-dg"])
+	assert.Nil(t, err)
+	otherDg := dgCopy.(*DeviceGroup)
+	otherDg.DeviceGroupId = aStr("other-dg")
+	otherDg.Device = map[string]*DeviceGroupDevice{}
+	site.DeviceGroup["other-dg"] = otherDg
+
+	return device
+}
+
+// enterprisePath returns a path below the sample enterprise
+func enterprisePath(elems ...*pb.PathElem) *pb.Path {
+	return &pb.Path{Elem: append([]*pb.PathElem{
+		{Name: "enterprises"},
+		{Name: "enterprise", Key: map[string]string{"enterprise-id": "sample-ent"}},
+	}, elems...)}
+}
+
+// sitePath returns a path to a list element in the sample site
+func sitePath(name string, key string, id string) *pb.Path {
+	return enterprisePath(
+		&pb.PathElem{Name: "site", Key: map[string]string{"site-id": "sample-site"}},
+		&pb.PathElem{Name: name, Key: map[string]string{key: id}},
+		&pb.PathElem{Name: "description"})
+}
+
+func TestAffectedObjects(t *testing.T) {
+	s := NewSynchronizer()
+	device := buildTargetsDevice(t)
+
+	dg := cacheKey(CacheModelDeviceGroup, "sample-dg")
+	otherDg := cacheKey(CacheModelDeviceGroup, "other-dg")
+	slice := cacheKey(CacheModelSlice, "sample-slice")
+
+	tests := []struct {
+		name     string
+		path     *pb.Path
+		expected syncTargets
+	}{
+		{"root", &pb.Path{}, nil},
+		{"connectivity-service", &pb.Path{Elem: []*pb.PathElem{{Name: "connectivity-services"}}}, nil},
+		{"enterprise", enterprisePath(), syncTargets{dg: true, otherDg: true, slice: true}},
+		{"unknown enterprise", &pb.Path{Elem: []*pb.PathElem{{Name: "enterprises"}, {Name: "enterprise", Key: map[string]string{"enterprise-id": "missing"}}}}, syncTargets{}},
+		{"device-group", sitePath("device-group", "device-group-id", "sample-dg"), syncTargets{dg: true, slice: true}},
+		{"unused device-group", sitePath("device-group", "device-group-id", "other-dg"), syncTargets{otherDg: true}},
+		{"slice", sitePath("slice", "slice-id", "sample-slice"), syncTargets{slice: true}},
+		{"ip-domain", sitePath("ip-domain", "ip-domain-id", "sample-ipd"), syncTargets{dg: true, otherDg: true, slice: true}},
+		{"device", sitePath("device", "device-id", "sample-device"), syncTargets{dg: true, slice: true}},
+		{"sim-card", sitePath("sim-card", "sim-id", "sample-sim"), syncTargets{dg: true, slice: true}},
+		{"upf", sitePath("upf", "upf-id", "sample-upf"), syncTargets{slice: true}},
+		{"small-cell", sitePath("small-cell", "small-cell-id", "myradio"), syncTargets{slice: true}},
+		{"imsi-definition", enterprisePath(&pb.PathElem{Name: "site", Key: map[string]string{"site-id": "sample-site"}}, &pb.PathElem{Name: "imsi-definition"}), syncTargets{dg: true, otherDg: true, slice: true}},
+		{"application", enterprisePath(&pb.PathElem{Name: "application", Key: map[string]string{"application-id": "sample-app2"}}), syncTargets{slice: true}},
+		{"traffic-class", enterprisePath(&pb.PathElem{Name: "traffic-class", Key: map[string]string{"traffic-class-id": "sample-traffic-class"}}), syncTargets{dg: true, otherDg: true, slice: true}},
+		{"template", enterprisePath(&pb.PathElem{Name: "template", Key: map[string]string{"template-id": "sample-template"}}), syncTargets{}},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, s.affectedObjects(device, test.path), test.name)
+	}
+}
+
+func TestMergePath(t *testing.T) {
+	dgPath := sitePath("device-group", "device-group-id", "sample-dg")
+	slicePath := sitePath("slice", "slice-id", "sample-slice")
+
+	update := &ConfigUpdate{path: slicePath}
+	update.mergePath(&ConfigUpdate{path: dgPath})
+	assert.Equal(t, "enterprises/enterprise[enterprise-id=sample-ent]/site[site-id=sample-site]", gnmi.PathToString(update.path))
+
+	// An older update that synchronized everything still does
+	update.mergePath(&ConfigUpdate{})
+	assert.Nil(t, update.path)
+}
+
+func TestSynchronizeUpdateTargeted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher))
+
+	device := buildTargetsDevice(t)
+
+	// Only the device-group that changed is pushed
+	mockPusher.EXPECT().PushUpdate("http://5gcore/v1/device-group/other-dg", gomock.Any()).Return(nil)
+
+	pushErrors, _, err := s.synchronizeUpdate(&ConfigUpdate{config: device, path: sitePath("device-group", "device-group-id", "other-dg")})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, pushErrors)
+}
@@ -0,0 +1,186 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Template implements filling in the unset fields of a slice from its template.
+
+package synchronizer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/onosproject/sdcore-adapter/pkg/refgraph"
+)
+
+// EffectiveSlice is the values that a slice is rendered with, after its unset fields are
+// inherited from its template
+type EffectiveSlice struct {
+	Enterprise        string   `json:"enterprise"`
+	Site              string   `json:"site"`
+	Slice             string   `json:"slice"`
+	Template          string   `json:"template,omitempty"`
+	Sst               *uint8   `json:"sst,omitempty"`
+	Sd                *uint32  `json:"sd,omitempty"`
+	DefaultBehavior   *string  `json:"default-behavior,omitempty"`
+	Uplink            *uint64  `json:"uplink,omitempty"`
+	Downlink          *uint64  `json:"downlink,omitempty"`
+	UplinkBurstSize   *uint32  `json:"uplink-burst-size,omitempty"`
+	DownlinkBurstSize *uint32  `json:"downlink-burst-size,omitempty"`
+	Inherited         []string `json:"inherited"`
+}
+
+// WithSliceTemplates binds slices to the templates that they inherit their unset fields
+// from, by slice ID. A slice with no binding uses defaultTemplate, or no template if it is
+// empty.
+func WithSliceTemplates(bindings map[string]string, defaultTemplate string) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.sliceTemplates = &refgraph.SliceTemplates{Bindings: bindings, Default: defaultTemplate}
+	}
+}
+
+// ParseSliceTemplates parses a list of slices and their templates, such as
+// "slice1=template1,slice2=template2", into a map from each slice ID to its template ID
+func ParseSliceTemplates(spec string) (map[string]string, error) {
+	bindings := map[string]string{}
+	if strings.TrimSpace(spec) == "" {
+		return bindings, nil
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if (len(parts) != 2) || (parts[0] == "") || (parts[1] == "") {
+			return nil, fmt.Errorf("Invalid slice template entry %q; must be slice=template", entry)
+		}
+		if _, okay := bindings[parts[0]]; okay {
+			return nil, fmt.Errorf("Slice %s has more than one template entry", parts[0])
+		}
+		bindings[parts[0]] = parts[1]
+	}
+	return bindings, nil
+}
+
+// applyTemplate returns a copy of slice in which the fields that are unset are inherited
+// from the slice's template, and the names of the fields that were inherited. If the slice
+// has no template, the slice itself is returned. Traffic classes are not inherited: in the
+// model, a traffic class belongs to an application endpoint, and a template has none.
+func (s *Synchronizer) applyTemplate(scope *AetherScope, slice *Slice) (*Slice, []string) {
+	inherited := []string{}
+
+	tpID := s.sliceTemplates.Template(scope.Enterprise, *slice.SliceId)
+	if tpID == "" {
+		return slice, inherited
+	}
+	tp := scope.Enterprise.Template[tpID]
+
+	effective := *slice
+	if slice.Mbr != nil {
+		mbr := *slice.Mbr
+		effective.Mbr = &mbr
+	}
+
+	if (effective.Sst == nil) && (tp.Sst != nil) {
+		effective.Sst = tp.Sst
+		inherited = append(inherited, "sst")
+	}
+	if (effective.Sd == nil) && (tp.Sd != nil) {
+		effective.Sd = tp.Sd
+		inherited = append(inherited, "sd")
+	}
+	if (effective.DefaultBehavior == nil) && (tp.DefaultBehavior != nil) {
+		effective.DefaultBehavior = tp.DefaultBehavior
+		inherited = append(inherited, "default-behavior")
+	}
+
+	if (tp.Slice != nil) && (tp.Slice.Mbr != nil) {
+		tpMbr := tp.Slice.Mbr
+		if effective.Mbr == nil {
+			effective.Mbr = &SliceMbr{}
+		}
+		if (effective.Mbr.Uplink == nil) && (tpMbr.Uplink != nil) {
+			effective.Mbr.Uplink = tpMbr.Uplink
+			inherited = append(inherited, "mbr/uplink")
+		}
+		if (effective.Mbr.Downlink == nil) && (tpMbr.Downlink != nil) {
+			effective.Mbr.Downlink = tpMbr.Downlink
+			inherited = append(inherited, "mbr/downlink")
+		}
+		if (effective.Mbr.UplinkBurstSize == nil) && (tpMbr.UplinkBurstSize != nil) {
+			effective.Mbr.UplinkBurstSize = tpMbr.UplinkBurstSize
+			inherited = append(inherited, "mbr/uplink-burst-size")
+		}
+		if (effective.Mbr.DownlinkBurstSize == nil) && (tpMbr.DownlinkBurstSize != nil) {
+			effective.Mbr.DownlinkBurstSize = tpMbr.DownlinkBurstSize
+			inherited = append(inherited, "mbr/downlink-burst-size")
+		}
+	}
+
+	return &effective, inherited
+}
+
+// effectiveSlice reports the values that slice is rendered with
+func (s *Synchronizer) effectiveSlice(scope *AetherScope, slice *Slice) EffectiveSlice {
+	effective, inherited := s.applyTemplate(scope, slice)
+	report := EffectiveSlice{
+		Enterprise:      *scope.Enterprise.EnterpriseId,
+		Site:            *scope.Site.SiteId,
+		Slice:           *slice.SliceId,
+		Template:        s.sliceTemplates.Template(scope.Enterprise, *slice.SliceId),
+		Sst:             effective.Sst,
+		Sd:              effective.Sd,
+		DefaultBehavior: effective.DefaultBehavior,
+		Inherited:       inherited,
+	}
+	if effective.Mbr != nil {
+		report.Uplink = effective.Mbr.Uplink
+		report.Downlink = effective.Mbr.Downlink
+		report.UplinkBurstSize = effective.Mbr.UplinkBurstSize
+		report.DownlinkBurstSize = effective.Mbr.DownlinkBurstSize
+	}
+	return report
+}
+
+// GetEffectiveSlices returns the values that each slice in the most recent config is
+// rendered with, or only those of slices with ID sliceID if it is not empty
+func (s *Synchronizer) GetEffectiveSlices(sliceID string) ([]EffectiveSlice, error) {
+	s.reconcileMutex.Lock()
+	config := s.lastConfig
+	s.reconcileMutex.Unlock()
+
+	if config == nil {
+		return nil, fmt.Errorf("No configuration has been received yet")
+	}
+	device, okay := config.(*RootDevice)
+	if !okay {
+		return nil, fmt.Errorf("Configuration is not a RootDevice")
+	}
+
+	// A site is in one scope per connectivity service; report each slice once
+	seen := map[string]bool{}
+	slices := []EffectiveSlice{}
+	for _, scope := range deviceScopes(device) {
+		for id, slice := range scope.Site.Slice {
+			if (sliceID != "") && (id != sliceID) {
+				continue
+			}
+			key := fmt.Sprintf("%s/%s/%s", *scope.Enterprise.EnterpriseId, *scope.Site.SiteId, id)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			slices = append(slices, s.effectiveSlice(scope, slice))
+		}
+	}
+
+	sort.Slice(slices, func(i, j int) bool {
+		if slices[i].Enterprise != slices[j].Enterprise {
+			return slices[i].Enterprise < slices[j].Enterprise
+		}
+		if slices[i].Site != slices[j].Site {
+			return slices[i].Site < slices[j].Site
+		}
+		return slices[i].Slice < slices[j].Slice
+	})
+
+	return slices, nil
+}
@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"github.com/golang/mock/gomock"
+	models "github.com/onosproject/aether-models/models/aether-2.0.x/api"
+	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// sampleTemplates makes the default template the template of every slice
+var sampleTemplates = WithSliceTemplates(nil, "default")
+
+// addDefaultTemplate gives the sample enterprise a default template, and removes the
+// fields of the sample slice that it provides
+func addDefaultTemplate(device *RootDevice) *AetherScope {
+	ent := device.Enterprises.Enterprise["sample-ent"]
+	ent.Template = map[string]*Template{
+		"default": {
+			TemplateId:      aStr("default"),
+			Sst:             aUint8(1),
+			Sd:              aUint32(0x10203),
+			DefaultBehavior: aStr("ALLOW-ALL"),
+			Slice: &models.OnfEnterprise_Enterprises_Enterprise_Template_Slice{
+				Mbr: &models.OnfEnterprise_Enterprises_Enterprise_Template_Slice_Mbr{
+					Uplink:          aUint64(1000),
+					UplinkBurstSize: aUint32(5000),
+				},
+			},
+		},
+	}
+
+	slice := ent.Site["sample-site"].Slice["sample-slice"]
+	slice.Sst = nil
+	slice.DefaultBehavior = nil
+	slice.Mbr.Uplink = nil
+
+	return deviceScopes(device)[0]
+}
+
+func TestApplyTemplate(t *testing.T) {
+	device := BuildSampleDevice()
+	scope, err := BuildScope(device, "sample-ent", "sample-site", "sample-cs")
+	assert.Nil(t, err)
+	slice := scope.Site.Slice["sample-slice"]
+	s := NewSynchronizer(sampleTemplates)
+
+	// Without a template, the slice is rendered as it is
+	effective, inherited := s.applyTemplate(scope, slice)
+	assert.Equal(t, slice, effective)
+	assert.Empty(t, inherited)
+
+	// The template is only used by slices that are bound to it
+	scope = addDefaultTemplate(device)
+	unbound := NewSynchronizer()
+	effective, inherited = unbound.applyTemplate(scope, slice)
+	assert.Equal(t, slice, effective)
+	assert.Empty(t, inherited)
+
+	effective, inherited = s.applyTemplate(scope, slice)
+	assert.Equal(t, []string{"sst", "default-behavior", "mbr/uplink", "mbr/uplink-burst-size"}, inherited)
+	assert.Equal(t, uint8(1), *effective.Sst)
+	assert.Equal(t, "ALLOW-ALL", *effective.DefaultBehavior)
+	assert.Equal(t, uint64(1000), *effective.Mbr.Uplink)
+	assert.Equal(t, uint32(5000), *effective.Mbr.UplinkBurstSize)
+
+	// Fields the slice sets win, and the slice itself is not changed
+	assert.Equal(t, uint32(111), *effective.Sd)
+	assert.Equal(t, uint64(444), *effective.Mbr.Downlink)
+	assert.Nil(t, slice.Sst)
+	assert.Nil(t, slice.Mbr.Uplink)
+}
+
+func TestRenderSliceWithTemplate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), sampleTemplates)
+
+	device := BuildSampleDevice()
+	scope := addDefaultTemplate(device)
+	slice := scope.Site.Slice["sample-slice"]
+
+	// Without its template, validateSlice would reject the slice
+	coreSlice, err := s.renderSlice(scope, slice)
+	assert.Nil(t, err)
+	assert.Equal(t, "1", coreSlice.ID.Sst)
+	last := coreSlice.ApplicationFilteringRules[len(coreSlice.ApplicationFilteringRules)-1]
+	assert.Equal(t, "ALLOW-ALL", last.Name)
+}
+
+func TestGetEffectiveSlices(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithSliceTemplates(map[string]string{"sample-slice": "default"}, ""))
+
+	_, err := s.GetEffectiveSlices("")
+	assert.EqualError(t, err, "No configuration has been received yet")
+
+	device := BuildSampleDevice()
+	addDefaultTemplate(device)
+	s.setLastConfig(device)
+
+	slices, err := s.GetEffectiveSlices("")
+	assert.Nil(t, err)
+	assert.Equal(t, []EffectiveSlice{{
+		Enterprise:        "sample-ent",
+		Site:              "sample-site",
+		Slice:             "sample-slice",
+		Template:          "default",
+		Sst:               aUint8(1),
+		Sd:                aUint32(111),
+		DefaultBehavior:   aStr("ALLOW-ALL"),
+		Uplink:            aUint64(1000),
+		Downlink:          aUint64(444),
+		UplinkBurstSize:   aUint32(5000),
+		DownlinkBurstSize: nil,
+		Inherited:         []string{"sst", "default-behavior", "mbr/uplink", "mbr/uplink-burst-size"},
+	}}, slices)
+
+	slices, err = s.GetEffectiveSlices("missing")
+	assert.Nil(t, err)
+	assert.Empty(t, slices)
+}
+
+func TestParseSliceTemplates(t *testing.T) {
+	bindings, err := ParseSliceTemplates(" slice1=tp1, slice2=tp2")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"slice1": "tp1", "slice2": "tp2"}, bindings)
+
+	bindings, err = ParseSliceTemplates("")
+	assert.Nil(t, err)
+	assert.Empty(t, bindings)
+
+	_, err = ParseSliceTemplates("slice1")
+	assert.EqualError(t, err, `Invalid slice template entry "slice1"; must be slice=template`)
+	_, err = ParseSliceTemplates("slice1=tp1,slice1=tp2")
+	assert.EqualError(t, err, "Slice slice1 has more than one template entry")
+}
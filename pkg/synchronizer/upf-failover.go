@@ -0,0 +1,254 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// UPF failover implements switching a slice to a standby UPF when the config endpoint of
+// its UPF has been unreachable for longer than the failover window.
+
+package synchronizer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/onosproject/sdcore-adapter/pkg/refgraph"
+)
+
+// WithUpfFailoverWindow sets how long the config endpoint of a slice's UPF must be
+// unreachable before the slice fails over to a standby UPF. Zero disables failover.
+func WithUpfFailoverWindow(window time.Duration) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.upfFailoverWindow = window
+	}
+}
+
+// WithUpfProbeInterval sets the interval between probes of the UPF config endpoints
+func WithUpfProbeInterval(interval time.Duration) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.upfProbeInterval = interval
+	}
+}
+
+// WithUpfStandbys sets the standby UPFs of each UPF, by UPF ID. A slice can only fail over
+// to the standbys of its own UPF that are in its site.
+func WithUpfStandbys(standbys map[string][]string) SynchronizerOption {
+	return func(s *Synchronizer) {
+		s.upfStandbys = standbys
+	}
+}
+
+// ParseUpfStandbys parses a list of UPFs and their standbys, such as
+// "upf1=upf2+upf3,upf4=upf5", into a map from each UPF ID to the IDs of its standbys
+func ParseUpfStandbys(spec string) (map[string][]string, error) {
+	standbys := map[string][]string{}
+	if strings.TrimSpace(spec) == "" {
+		return standbys, nil
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if (len(parts) != 2) || (parts[0] == "") || (parts[1] == "") {
+			return nil, fmt.Errorf("Invalid UPF standby entry %q; must be upf=standby[+standby...]", entry)
+		}
+		if _, okay := standbys[parts[0]]; okay {
+			return nil, fmt.Errorf("UPF %s has more than one standby entry", parts[0])
+		}
+		for _, standby := range strings.Split(parts[1], "+") {
+			if (standby == "") || (standby == parts[0]) {
+				return nil, fmt.Errorf("Invalid standby %q for UPF %s", standby, parts[0])
+			}
+			standbys[parts[0]] = append(standbys[parts[0]], standby)
+		}
+	}
+	return standbys, nil
+}
+
+// upfCandidateIDs returns the IDs of the UPFs that a slice is pushed to, its own UPF
+// first. With failover enabled, these are followed by the configured standbys of its UPF.
+func (s *Synchronizer) upfCandidateIDs(scope *AetherScope, slice *Slice) []string {
+	if slice.Upf == nil {
+		return []string{}
+	}
+	ids := []string{*slice.Upf}
+	if s.upfFailoverWindow > 0 {
+		ids = append(ids, s.upfStandbys[*slice.Upf]...)
+	}
+	return ids
+}
+
+// buildGraph builds the reference graph of device, including the templates of slices, and
+// the standby UPFs that slices can fail over to
+func (s *Synchronizer) buildGraph(device *RootDevice) *refgraph.Graph {
+	opts := []refgraph.BuildOption{refgraph.WithSliceTemplates(s.sliceTemplates)}
+	if s.upfFailoverWindow > 0 {
+		opts = append(opts, refgraph.WithStandbyUpfs(s.upfStandbys))
+	}
+	return refgraph.Build(device, opts...)
+}
+
+// upfSliceID is the ID that a slice is cached and reported under for a UPF. The slice's
+// own UPF uses the slice ID, so that nothing changes when failover is disabled.
+func upfSliceID(sliceID string, upfID string, primary bool) string {
+	if primary {
+		return sliceID
+	}
+	return fmt.Sprintf("%s@%s", sliceID, upfID)
+}
+
+// upfReachable returns true if the result of a request to a UPF shows that it answered,
+// even if it answered with an error status
+func upfReachable(err error) bool {
+	if err == nil {
+		return true
+	}
+	_, okay := err.(*PushError)
+	return okay
+}
+
+// upfRecord records the result of a request to the config endpoint of a UPF
+func (s *Synchronizer) upfRecord(endpoint string, err error) {
+	s.upfMutex.Lock()
+	defer s.upfMutex.Unlock()
+
+	if upfReachable(err) {
+		if _, okay := s.upfDownSince[endpoint]; okay {
+			log.Infof("UPF config endpoint %s is reachable again", endpoint)
+			delete(s.upfDownSince, endpoint)
+		}
+		KpiUpfReachable.WithLabelValues(endpoint).Set(1)
+		return
+	}
+
+	if _, okay := s.upfDownSince[endpoint]; !okay {
+		log.Warnf("UPF config endpoint %s is unreachable: %v", endpoint, err)
+		s.upfDownSince[endpoint] = time.Now()
+	}
+	KpiUpfReachable.WithLabelValues(endpoint).Set(0)
+}
+
+// upfPushResult records the result of a push of a slice to a UPF, which shows whether
+// the UPF's config endpoint is reachable as well as any probe does
+func (s *Synchronizer) upfPushResult(kind string, endpoint string, err error) {
+	if kind != CacheModelSliceUpf {
+		return
+	}
+	if i := strings.Index(endpoint, "/v1/config/network-slices"); i >= 0 {
+		s.upfRecord(endpoint[:i], err)
+	}
+}
+
+// upfDown returns true if a UPF's config endpoint has been unreachable for longer than
+// the failover window. A UPF with no config endpoint cannot be probed, and is never down.
+func (s *Synchronizer) upfDown(upf *Upf) bool {
+	if (s.upfFailoverWindow <= 0) || (upf.ConfigEndpoint == nil) {
+		return false
+	}
+
+	s.upfMutex.Lock()
+	defer s.upfMutex.Unlock()
+
+	downSince, okay := s.upfDownSince[*upf.ConfigEndpoint]
+	return okay && (time.Since(downSince) >= s.upfFailoverWindow)
+}
+
+// activeUpf returns the UPF that the core should send a slice's traffic to: the first
+// candidate that is not down. If every candidate is down, the slice's own UPF is used.
+func (s *Synchronizer) activeUpf(scope *AetherScope, slice *Slice) (*Upf, error) {
+	primary, err := s.GetUpf(scope, slice.Upf)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, upfID := range s.upfCandidateIDs(scope, slice) {
+		upf, okay := scope.Site.Upf[upfID]
+		if !okay || (validateUpf(upf) != nil) {
+			continue
+		}
+		if !s.upfDown(upf) {
+			return upf, nil
+		}
+	}
+
+	log.Debugf("Slice %s has no reachable UPF, staying on %s", *slice.SliceId, *slice.Upf)
+	return primary, nil
+}
+
+// activeUpfs returns the active UPF of every slice in config, by status key
+func (s *Synchronizer) activeUpfs(device *RootDevice) map[string]string {
+	active := map[string]string{}
+	for _, scope := range deviceScopes(device) {
+		cs := *scope.ConnectivityService.ConnectivityServiceId
+		for sliceID, slice := range scope.Site.Slice {
+			if slice.Upf == nil {
+				continue
+			}
+			upf, err := s.activeUpf(scope, slice)
+			if err != nil {
+				continue
+			}
+			active[statusKey(CacheModelSlice, sliceID, cs)] = *upf.UpfId
+		}
+	}
+	return active
+}
+
+// probeUpfs checks that the config endpoint of every UPF in the most recent config is
+// reachable. If that changes the active UPF of any slice, the latest config is
+// synchronized again, so that the core is told about the new UPF.
+func (s *Synchronizer) probeUpfs() {
+	s.reconcileMutex.Lock()
+	config := s.lastConfig
+	s.reconcileMutex.Unlock()
+
+	device, okay := config.(*RootDevice)
+	if !okay {
+		return
+	}
+
+	if s.fetcher != nil {
+		probed := map[string]bool{}
+		for _, scope := range deviceScopes(device) {
+			for _, upf := range scope.Site.Upf {
+				if (upf.ConfigEndpoint == nil) || probed[*upf.ConfigEndpoint] {
+					continue
+				}
+				probed[*upf.ConfigEndpoint] = true
+				_, err := s.fetcher.Fetch(fmt.Sprintf("%s/v1/config/network-slices", *upf.ConfigEndpoint))
+				s.upfRecord(*upf.ConfigEndpoint, err)
+			}
+		}
+	}
+
+	active := s.activeUpfs(device)
+
+	s.upfMutex.Lock()
+	changed := false
+	// The first probe only records where each slice starts out
+	if s.upfActive != nil {
+		for key, upfID := range active {
+			if previous, okay := s.upfActive[key]; okay && (previous != upfID) {
+				log.Infof("%s moves from UPF %s to UPF %s", key, previous, upfID)
+				changed = true
+			}
+		}
+	}
+	s.upfActive = active
+	s.upfMutex.Unlock()
+
+	// The config may have been replaced by a Set during the probes, so the latest one is
+	// synchronized rather than the one that was probed
+	if changed {
+		s.requestResync()
+	}
+}
+
+// upfProbeLoop probes the UPF config endpoints every upfProbeInterval
+func (s *Synchronizer) upfProbeLoop() {
+	log.Infof("Starting UPF probe loop, interval=%s, failoverWindow=%s", s.upfProbeInterval, s.upfFailoverWindow)
+	ticker := time.NewTicker(s.upfProbeInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.probeUpfs()
+	}
+}
@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package synchronizer
+
+import (
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/onosproject/sdcore-adapter/pkg/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// sampleStandbys makes standby-upf the standby of the sample UPF
+var sampleStandbys = map[string][]string{"sample-upf": {"standby-upf"}}
+
+// addStandbyUpf adds a UPF that no slice uses to the sample site
+func addStandbyUpf(device *RootDevice) *AetherScope {
+	site := device.Enterprises.Enterprise["sample-ent"].Site["sample-site"]
+	site.Upf["standby-upf"] = &Upf{
+		UpfId:          aStr("standby-upf"),
+		Address:        aStr("3.4.5.6"),
+		ConfigEndpoint: aStr("http://standby-upf"),
+		Port:           aUint16(77),
+	}
+	return deviceScopes(device)[0]
+}
+
+func TestUpfFailover(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithUpfFailoverWindow(time.Minute), WithUpfStandbys(sampleStandbys))
+
+	scope := addStandbyUpf(BuildSampleDevice())
+	slice := scope.Site.Slice["sample-slice"]
+
+	// The slice is pushed to its own UPF and to the standby
+	pushes := []string{}
+	mockPusher.EXPECT().PushUpdate(gomock.Any(), gomock.Any()).DoAndReturn(func(endpoint string, data []byte) error {
+		pushes = append(pushes, endpoint)
+		return nil
+	}).Times(2)
+	pushFailures, err := s.SynchronizeSliceUPF(scope, slice)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, pushFailures)
+	assert.Equal(t, []string{"http://upf/v1/config/network-slices", "http://standby-upf/v1/config/network-slices"}, pushes)
+	assert.Contains(t, s.cache, cacheKey(CacheModelSliceUpf, "sample-slice@standby-upf"))
+
+	coreSlice, err := s.renderSlice(scope, slice)
+	assert.Nil(t, err)
+	assert.Equal(t, upf{Name: "2.3.4.5", Port: 66}, coreSlice.SiteInfo.Upf)
+
+	// Unreachable for less than the window, the slice stays put
+	s.upfRecord("http://upf", errors.New("connection refused"))
+	coreSlice, err = s.renderSlice(scope, slice)
+	assert.Nil(t, err)
+	assert.Equal(t, upf{Name: "2.3.4.5", Port: 66}, coreSlice.SiteInfo.Upf)
+
+	// Past the window, the core is told to use the standby
+	s.upfDownSince["http://upf"] = time.Now().Add(-2 * time.Minute)
+	coreSlice, err = s.renderSlice(scope, slice)
+	assert.Nil(t, err)
+	assert.Equal(t, upf{Name: "3.4.5.6", Port: 77}, coreSlice.SiteInfo.Upf)
+
+	// With every UPF down, the slice stays on its own UPF
+	s.upfDownSince["http://standby-upf"] = time.Now().Add(-2 * time.Minute)
+	coreSlice, err = s.renderSlice(scope, slice)
+	assert.Nil(t, err)
+	assert.Equal(t, upf{Name: "2.3.4.5", Port: 66}, coreSlice.SiteInfo.Upf)
+
+	// Any answer, even an error status, means the UPF is back
+	s.upfRecord("http://upf", &PushError{StatusCode: 500})
+	assert.NotContains(t, s.upfDownSince, "http://upf")
+}
+
+func TestUpfFailoverDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithUpfStandbys(sampleStandbys))
+
+	scope := addStandbyUpf(BuildSampleDevice())
+	slice := scope.Site.Slice["sample-slice"]
+
+	// Standbys are ignored, and a down UPF is not failed over
+	mockPusher.EXPECT().PushUpdate("http://upf/v1/config/network-slices", gomock.Any()).Return(nil)
+	pushFailures, err := s.SynchronizeSliceUPF(scope, slice)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, pushFailures)
+
+	s.upfDownSince["http://upf"] = time.Now().Add(-time.Hour)
+	coreSlice, err := s.renderSlice(scope, slice)
+	assert.Nil(t, err)
+	assert.Equal(t, upf{Name: "2.3.4.5", Port: 66}, coreSlice.SiteInfo.Upf)
+}
+
+func TestUpfFailoverUnconfiguredStandby(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithUpfFailoverWindow(time.Minute))
+
+	// A UPF that no slice uses is a spare, not a standby, unless it is configured as one
+	scope := addStandbyUpf(BuildSampleDevice())
+	slice := scope.Site.Slice["sample-slice"]
+	assert.Equal(t, []string{"sample-upf"}, s.upfCandidateIDs(scope, slice))
+	mockPusher.EXPECT().PushUpdate("http://upf/v1/config/network-slices", gomock.Any()).Return(nil)
+	pushFailures, err := s.SynchronizeSliceUPF(scope, slice)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, pushFailures)
+}
+
+func TestParseUpfStandbys(t *testing.T) {
+	standbys, err := ParseUpfStandbys("upf1=upf2+upf3, upf4=upf5")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]string{"upf1": {"upf2", "upf3"}, "upf4": {"upf5"}}, standbys)
+
+	standbys, err = ParseUpfStandbys("")
+	assert.Nil(t, err)
+	assert.Empty(t, standbys)
+
+	_, err = ParseUpfStandbys("upf1")
+	assert.EqualError(t, err, `Invalid UPF standby entry "upf1"; must be upf=standby[+standby...]`)
+	_, err = ParseUpfStandbys("upf1=upf1")
+	assert.EqualError(t, err, `Invalid standby "upf1" for UPF upf1`)
+	_, err = ParseUpfStandbys("upf1=upf2,upf1=upf3")
+	assert.EqualError(t, err, "UPF upf1 has more than one standby entry")
+}
+
+func TestUpfFailoverStandbyPushFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithUpfFailoverWindow(time.Minute), WithUpfStandbys(sampleStandbys))
+
+	scope := addStandbyUpf(BuildSampleDevice())
+	slice := scope.Site.Slice["sample-slice"]
+
+	// A standby that cannot be reached does not fail the slice, but is marked down
+	mockPusher.EXPECT().PushUpdate("http://upf/v1/config/network-slices", gomock.Any()).Return(nil)
+	mockPusher.EXPECT().PushUpdate("http://standby-upf/v1/config/network-slices", gomock.Any()).Return(errors.New("no route to host"))
+	pushFailures, err := s.SynchronizeSliceUPF(scope, slice)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, pushFailures)
+	assert.Contains(t, s.upfDownSince, "http://standby-upf")
+}
+
+func TestProbeUpfs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockPusher := mocks.NewMockPusherInterface(ctrl)
+	mockFetcher := mocks.NewMockFetcherInterface(ctrl)
+	s := NewSynchronizer(WithPusher(mockPusher), WithFetcher(mockFetcher), WithUpfFailoverWindow(time.Minute), WithUpfStandbys(sampleStandbys))
+
+	device := BuildSampleDevice()
+	addStandbyUpf(device)
+	s.setLastConfig(device)
+
+	// The first probe records where each slice starts out, without synchronizing
+	mockFetcher.EXPECT().Fetch("http://upf/v1/config/network-slices").Return(nil, errors.New("connection refused")).Times(2)
+	mockFetcher.EXPECT().Fetch("http://standby-upf/v1/config/network-slices").Return([]byte(`[]`), nil).Times(2)
+	s.probeUpfs()
+	assert.Equal(t, map[string]string{statusKey(CacheModelSlice, "sample-slice", "sample-cs"): "sample-upf"}, s.upfActive)
+	assert.Len(t, s.updateChannel, 0)
+
+	// Once the UPF has been down for the window, the slice moves and is synchronized
+	s.upfDownSince["http://upf"] = time.Now().Add(-2 * time.Minute)
+	s.probeUpfs()
+	assert.Equal(t, map[string]string{statusKey(CacheModelSlice, "sample-slice", "sample-cs"): "standby-upf"}, s.upfActive)
+	assert.Len(t, s.updateChannel, 1)
+	update := <-s.updateChannel
+	assert.Equal(t, device, update.config)
+	assert.Nil(t, update.path)
+}
@@ -59,6 +59,10 @@ func DerefInt8Ptr(u *int8, def int8) int8 {
 	return *u
 }
 
+// MinFullImsiDigits is the number of digits from which FormatImsi takes a subscriber to be
+// a full IMSI
+const MinFullImsiDigits = 13
+
 // FormatImsi formats MCC, MNC, ENT, and SUB into an IMSI, according to a format specifier
 func FormatImsi(format string, mcc string, mnc string, ent uint32, sub uint64) (uint64, error) {
 	var imsi uint64
@@ -68,7 +72,7 @@ func FormatImsi(format string, mcc string, mnc string, ent uint32, sub uint64) (
 	var err error
 	mult = 1
 
-	if len(strconv.FormatUint(sub, 10)) >= 13 {
+	if len(strconv.FormatUint(sub, 10)) >= MinFullImsiDigits {
 		// If the subscriber is at least 13 digits long, then the user is passing us a full
 		// IMSI and we dont want to change the MCC, MNC, or ENT bits. The reason we check
 		// for 13 digits instead of for a full 15 is the assumption that there could be
@@ -199,15 +203,6 @@ func MaskSubscriberImsiDef(i *ImsiDefinition, sub uint64) (uint64, error) {
 	return MaskSubscriberImsi(format, sub)
 }
 
-// ProtoStringToProtoNumber converts a protocol name to a number
-func ProtoStringToProtoNumber(s string) (uint8, error) {
-	n, okay := map[string]uint8{"TCP": 6, "UDP": 17}[s]
-	if !okay {
-		return 0, fmt.Errorf("Unknown protocol %s", s)
-	}
-	return n, nil
-}
-
 // aStr facilitates easy declaring of pointers to strings
 func aStr(s string) *string {
 	return &s
@@ -14,7 +14,7 @@ import (
 // Validation functions, return an error if the given struct is missing data that
 // prevents synchronization.
 
-// TODO: See if there is a way to do this automatically using ygot
+// Semantic checks of values, such as ranges and formats, are in the validation package.
 
 // return error if VCS cannot be synchronized due to missing data
 func validateSlice(slice *Slice) error {
@@ -27,11 +27,29 @@ func validateSlice(slice *Slice) error {
 	return nil
 }
 
-// return error if IpDomain cannot be synchronized due to missing data
+// return error if IpDomain cannot be synchronized due to missing or malformed data. The
+// DNS servers may be of either address family, so that an IPv6 pool can use IPv4 DNS.
 func validateIPDomain(ipd *IpDomain) error {
 	if ipd.Subnet == nil {
 		return fmt.Errorf("Subnet is nil")
 	}
+	_, family, err := parsePrefix(*ipd.Subnet)
+	if err != nil {
+		return fmt.Errorf("Subnet is invalid: %v", err)
+	}
+	if (family == familyIPv6) && (ipd.Mtu != nil) && (*ipd.Mtu < MinIPv6MTU) {
+		return fmt.Errorf("Mtu %d is less than the IPv6 minimum of %d", *ipd.Mtu, MinIPv6MTU)
+	}
+	if ipd.DnsPrimary != nil {
+		if _, _, err := parseIP(*ipd.DnsPrimary); err != nil {
+			return fmt.Errorf("DnsPrimary is invalid: %v", err)
+		}
+	}
+	if ipd.DnsSecondary != nil {
+		if _, _, err := parseIP(*ipd.DnsSecondary); err != nil {
+			return fmt.Errorf("DnsSecondary is invalid: %v", err)
+		}
+	}
 	return nil
 }
 
@@ -46,6 +46,34 @@ func TestValidateIPDomain(t *testing.T) {
 	i = &IpDomain{}
 	err = validateIPDomain(i)
 	assert.EqualError(t, err, "Subnet is nil")
+
+	// IPv6 pool with dual-stack DNS
+	i = &IpDomain{
+		Subnet:       aStr("2001:db8:1::/48"),
+		DnsPrimary:   aStr("2001:4860:4860::8888"),
+		DnsSecondary: aStr("8.8.8.8"),
+		Mtu:          aUint16(1400),
+	}
+	err = validateIPDomain(i)
+	assert.Nil(t, err)
+
+	i.Mtu = aUint16(1200)
+	err = validateIPDomain(i)
+	assert.EqualError(t, err, "Mtu 1200 is less than the IPv6 minimum of 1280")
+
+	i.Mtu = nil
+	i.DnsSecondary = aStr("dns.example.com")
+	err = validateIPDomain(i)
+	assert.EqualError(t, err, "DnsSecondary is invalid: dns.example.com is not an IP address")
+
+	// Malformed and mixed-family subnets
+	i = &IpDomain{Subnet: aStr("10.0.0.0/64")}
+	err = validateIPDomain(i)
+	assert.EqualError(t, err, "Subnet is invalid: 10.0.0.0/64 is not a valid prefix")
+
+	i = &IpDomain{Subnet: aStr("::ffff:10.0.0.0/104")}
+	err = validateIPDomain(i)
+	assert.EqualError(t, err, "Subnet is invalid: ::ffff:10.0.0.0 mixes IPv4 and IPv6")
 }
 
 func TestValidateSmallCell(t *testing.T) {
@@ -10,8 +10,8 @@ import (
 )
 
 // NewTarget creates a new target
-func NewTarget(model *gnmi.Model, config []byte, callback gnmi.ConfigCallback) (*target, error) { //nolint
-	s, err := gnmi.NewServer(model, config, callback)
+func NewTarget(model *gnmi.Model, config []byte, callback gnmi.ConfigCallback, opts ...gnmi.ServerOption) (*target, error) { //nolint
+	s, err := gnmi.NewServer(model, config, callback, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -49,6 +49,14 @@ func (m *MockPusherInterface) PushDelete(arg0 string) error {
 	return ret0
 }
 
+// UpdateMethod mocks base method.
+func (m *MockPusherInterface) UpdateMethod(arg0 string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMethod", arg0)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
 // PushUpdate indicates an expected call of PushUpdate.
 func (mr *MockPusherInterfaceMockRecorder) PushUpdate(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
@@ -60,3 +68,9 @@ func (mr *MockPusherInterfaceMockRecorder) PushDelete(arg0 interface{}) *gomock.
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PushDelete", reflect.TypeOf((*MockPusherInterface)(nil).PushDelete), arg0)
 }
+
+// UpdateMethod indicates an expected call of UpdateMethod.
+func (mr *MockPusherInterfaceMockRecorder) UpdateMethod(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMethod", reflect.TypeOf((*MockPusherInterface)(nil).UpdateMethod), arg0)
+}
@@ -0,0 +1,382 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Rules for the Aether 2.0 models. Fields that are nil are not checked here; the
+// synchronizer reports the ones it requires.
+
+package validation
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	models "github.com/onosproject/aether-models/models/aether-2.0.x/api"
+	"github.com/onosproject/sdcore-adapter/pkg/synchronizer"
+)
+
+const (
+	// MaxSd is the largest slice differentiator; the SD is 24 bits
+	MaxSd = 0xFFFFFF
+
+	// MinMTU is the smallest UE MTU allowed on an IPv4 subnet, which every IPv4 host must
+	// be able to receive
+	MinMTU = 576
+
+	// MinMTUv6 is the smallest UE MTU allowed on an IPv6 subnet. IPv6 requires at least 1280.
+	MinMTUv6 = 1280
+
+	// MaxMTU is the largest UE MTU allowed
+	MaxMTU = 9000
+
+	// MinBurst is the smallest burst size, in bytes, that passes a full-size packet
+	MinBurst = 1500
+
+	// MaxDnnLength is the longest DNN allowed by 3GPP TS 23.003
+	MaxDnnLength = 63
+
+	// DefaultImsiFormat is the IMSI format used when a site does not give one
+	DefaultImsiFormat = "CCCNNNEEESSSSSS"
+
+	// ImsiLength is the number of digits in an IMSI
+	ImsiLength = 15
+)
+
+// DefaultRules are the rules run by Validate when none are given
+var DefaultRules = []Rule{
+	{Name: "sst-sd", Check: checkSstSd},
+	{Name: "tac", Check: checkTac},
+	{Name: "imsi", Check: checkImsi},
+	{Name: "port-range", Check: checkPortRange},
+	{Name: "mbr", Check: checkMbr},
+	{Name: "dns", Check: checkDNS},
+	{Name: "mtu", Check: checkMtu},
+	{Name: "dnn", Check: checkDnn},
+	{Name: "address", Check: checkAddresses},
+}
+
+var (
+	tacRegexp      = regexp.MustCompile(`^[0-9A-Fa-f]{4,6}$`)
+	mccRegexp      = regexp.MustCompile(`^[0-9]{3}$`)
+	mncRegexp      = regexp.MustCompile(`^[0-9]{2,3}$`)
+	dnnRegexp      = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?)*$`)
+	dnnBadPrefixes = []string{"rac", "lac", "sgsn", "rnc"}
+	hostRegexp     = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?\.)*[A-Za-z]([A-Za-z0-9-]*[A-Za-z0-9])?\.?$`)
+)
+
+// The paths of objects in findings, in the same form as gnmi.PathToString
+
+func enterprisePath(entID string) string {
+	return fmt.Sprintf("enterprises/enterprise[enterprise-id=%s]", entID)
+}
+
+func sitePath(entID string, siteID string) string {
+	return fmt.Sprintf("%s/site[site-id=%s]", enterprisePath(entID), siteID)
+}
+
+// forEachSite calls f for every site of every enterprise
+func forEachSite(device *models.Device, f func(entID string, siteID string, site *models.OnfEnterprise_Enterprises_Enterprise_Site)) {
+	if device.Enterprises == nil {
+		return
+	}
+	for entID, enterprise := range device.Enterprises.Enterprise {
+		for siteID, site := range enterprise.Site {
+			f(entID, siteID, site)
+		}
+	}
+}
+
+// checkSstSdValues checks the SST and SD of a slice or template
+func checkSstSdValues(path string, sst *uint8, sd *uint32, r *Reporter) {
+	if (sst != nil) && (*sst == 0) {
+		r.Errorf(path+"/sst", "SST 0 is reserved; it must be 1 to 255")
+	}
+	if (sd != nil) && (*sd > MaxSd) {
+		r.Errorf(path+"/sd", "SD %d does not fit in 24 bits", *sd)
+	}
+}
+
+func checkSstSd(device *models.Device, r *Reporter) {
+	if device.Enterprises == nil {
+		return
+	}
+	for entID, enterprise := range device.Enterprises.Enterprise {
+		for tpID, tp := range enterprise.Template {
+			checkSstSdValues(fmt.Sprintf("%s/template[template-id=%s]", enterprisePath(entID), tpID), tp.Sst, tp.Sd, r)
+		}
+	}
+	forEachSite(device, func(entID string, siteID string, site *models.OnfEnterprise_Enterprises_Enterprise_Site) {
+		for sliceID, slice := range site.Slice {
+			checkSstSdValues(fmt.Sprintf("%s/slice[slice-id=%s]", sitePath(entID, siteID), sliceID), slice.Sst, slice.Sd, r)
+		}
+	})
+}
+
+func checkTac(device *models.Device, r *Reporter) {
+	forEachSite(device, func(entID string, siteID string, site *models.OnfEnterprise_Enterprises_Enterprise_Site) {
+		for scID, sc := range site.SmallCell {
+			if (sc.Tac != nil) && !tacRegexp.MatchString(*sc.Tac) {
+				r.Errorf(fmt.Sprintf("%s/small-cell[small-cell-id=%s]/tac", sitePath(entID, siteID), scID),
+					"TAC %s must be 4 to 6 hex digits", *sc.Tac)
+			}
+		}
+	})
+}
+
+func checkImsi(device *models.Device, r *Reporter) {
+	forEachSite(device, func(entID string, siteID string, site *models.OnfEnterprise_Enterprises_Enterprise_Site) {
+		def := site.ImsiDefinition
+		if def == nil {
+			return
+		}
+		path := sitePath(entID, siteID) + "/imsi-definition"
+
+		format := DefaultImsiFormat
+		if def.Format != nil {
+			format = *def.Format
+		}
+		if len(format) != ImsiLength {
+			r.Errorf(path+"/format", "format %s has %d digits; an IMSI has %d", format, len(format), ImsiLength)
+		}
+		if strings.Trim(format, "CNES0") != "" {
+			r.Errorf(path+"/format", "format %s may only contain C, N, E, S and 0", format)
+		}
+
+		if def.Mcc != nil {
+			if !mccRegexp.MatchString(*def.Mcc) {
+				r.Errorf(path+"/mcc", "MCC %s must be 3 digits", *def.Mcc)
+			} else if strings.Count(format, "C") != len(*def.Mcc) {
+				r.Errorf(path+"/format", "format %s has %d MCC digits, but MCC %s has %d", format, strings.Count(format, "C"), *def.Mcc, len(*def.Mcc))
+			}
+		}
+		if def.Mnc != nil {
+			mncDigits := strings.Count(format, "N")
+			if !mncRegexp.MatchString(*def.Mnc) {
+				r.Errorf(path+"/mnc", "MNC %s must be 2 or 3 digits", *def.Mnc)
+			} else if mncDigits < len(*def.Mnc) {
+				r.Errorf(path+"/format", "format %s has %d MNC digits, but MNC %s has %d", format, mncDigits, *def.Mnc, len(*def.Mnc))
+			} else if mncDigits > len(*def.Mnc) {
+				// FormatImsi pads the MNC with leading zeros, which is fine if that is intended
+				r.Warnf(path+"/format", "format %s has %d MNC digits, so MNC %s is padded with leading zeros", format, mncDigits, *def.Mnc)
+			}
+		}
+		if def.Enterprise != nil {
+			digits := len(strconv.FormatUint(uint64(*def.Enterprise), 10))
+			if digits > strings.Count(format, "E") {
+				r.Errorf(path+"/enterprise", "enterprise %d has %d digits, but format %s has room for %d", *def.Enterprise, digits, format, strings.Count(format, "E"))
+			}
+		}
+
+		subDigits := strings.Count(format, "S")
+		if subDigits == 0 {
+			r.Errorf(path+"/format", "format %s has no subscriber digits", format)
+			return
+		}
+		for simID, sim := range site.SimCard {
+			if sim.Imsi == nil {
+				continue
+			}
+			simPath := fmt.Sprintf("%s/sim-card[sim-id=%s]/imsi", sitePath(entID, siteID), simID)
+			digits := len(strconv.FormatUint(*sim.Imsi, 10))
+			switch {
+			case digits > ImsiLength:
+				r.Errorf(simPath, "IMSI %d has %d digits; an IMSI has %d", *sim.Imsi, digits, ImsiLength)
+			case digits >= synchronizer.MinFullImsiDigits:
+				// FormatImsi takes this to be a full IMSI, rather than subscriber digits
+			case digits > subDigits:
+				r.Errorf(simPath, "IMSI %d has %d digits, but the site's format has room for %d subscriber digits", *sim.Imsi, digits, subDigits)
+			}
+		}
+	})
+}
+
+func checkPortRange(device *models.Device, r *Reporter) {
+	if device.Enterprises == nil {
+		return
+	}
+	for entID, enterprise := range device.Enterprises.Enterprise {
+		for appID, app := range enterprise.Application {
+			for epID, ep := range app.Endpoint {
+				path := fmt.Sprintf("%s/application[application-id=%s]/endpoint[endpoint-id=%s]", enterprisePath(entID), appID, epID)
+				if (ep.PortStart != nil) && (*ep.PortStart == 0) {
+					r.Warnf(path+"/port-start", "port 0 is reserved")
+				}
+				if (ep.PortStart != nil) && (ep.PortEnd != nil) && (*ep.PortStart > *ep.PortEnd) {
+					r.Errorf(path+"/port-end", "port-end %d is before port-start %d", *ep.PortEnd, *ep.PortStart)
+				}
+				if (ep.PortStart == nil) && (ep.PortEnd != nil) {
+					r.Errorf(path+"/port-start", "port-end %d is set without port-start", *ep.PortEnd)
+				}
+				if (ep.Protocol == nil) || strings.EqualFold(*ep.Protocol, synchronizer.ProtocolAny) {
+					continue
+				}
+				n, err := synchronizer.ProtoStringToProtoNumber(*ep.Protocol)
+				if err != nil {
+					r.Errorf(path+"/protocol", "protocol %s is not an IANA protocol name or number", *ep.Protocol)
+				} else if (ep.PortStart != nil) && !synchronizer.ProtocolHasPorts(n) {
+					r.Errorf(path+"/protocol", "protocol %s has no ports, but port-start is set", *ep.Protocol)
+				}
+			}
+		}
+	}
+}
+
+// checkBurst checks one direction of a slice's MBR. The burst is in bytes, and the rate in
+// bits per second.
+func checkBurst(path string, direction string, rate *uint64, burst *uint32, r *Reporter) {
+	if (rate == nil) || (burst == nil) {
+		return
+	}
+	burstPath := fmt.Sprintf("%s/%s-burst-size", path, direction)
+	switch {
+	case *burst == 0:
+		r.Errorf(burstPath, "%s burst size of 0 would drop every packet", direction)
+	case *burst < MinBurst:
+		r.Warnf(burstPath, "%s burst size of %d bytes is smaller than a full-size packet", direction, *burst)
+	case (*rate > 0) && (uint64(*burst)*8 > *rate):
+		r.Warnf(burstPath, "%s burst size of %d bytes is more than one second of traffic at %d bps", direction, *burst, *rate)
+	}
+}
+
+func checkMbr(device *models.Device, r *Reporter) {
+	forEachSite(device, func(entID string, siteID string, site *models.OnfEnterprise_Enterprises_Enterprise_Site) {
+		for sliceID, slice := range site.Slice {
+			if slice.Mbr == nil {
+				continue
+			}
+			path := fmt.Sprintf("%s/slice[slice-id=%s]/mbr", sitePath(entID, siteID), sliceID)
+			checkBurst(path, "uplink", slice.Mbr.Uplink, slice.Mbr.UplinkBurstSize, r)
+			checkBurst(path, "downlink", slice.Mbr.Downlink, slice.Mbr.DownlinkBurstSize, r)
+		}
+	})
+}
+
+// ipDomainPath returns the path of an ip-domain
+func ipDomainPath(entID string, siteID string, ipdID string) string {
+	return fmt.Sprintf("%s/ip-domain[ip-domain-id=%s]", sitePath(entID, siteID), ipdID)
+}
+
+func checkDNS(device *models.Device, r *Reporter) {
+	forEachSite(device, func(entID string, siteID string, site *models.OnfEnterprise_Enterprises_Enterprise_Site) {
+		for ipdID, ipd := range site.IpDomain {
+			path := ipDomainPath(entID, siteID, ipdID)
+			if (ipd.DnsPrimary != nil) && (net.ParseIP(*ipd.DnsPrimary) == nil) {
+				r.Errorf(path+"/dns-primary", "DNS server %s is not an IP address", *ipd.DnsPrimary)
+			}
+			if (ipd.DnsSecondary != nil) && (net.ParseIP(*ipd.DnsSecondary) == nil) {
+				r.Errorf(path+"/dns-secondary", "DNS server %s is not an IP address", *ipd.DnsSecondary)
+			}
+			if (ipd.DnsPrimary == nil) && (ipd.DnsSecondary != nil) {
+				r.Warnf(path+"/dns-primary", "dns-secondary is set without dns-primary")
+			}
+		}
+	})
+}
+
+func checkMtu(device *models.Device, r *Reporter) {
+	forEachSite(device, func(entID string, siteID string, site *models.OnfEnterprise_Enterprises_Enterprise_Site) {
+		for ipdID, ipd := range site.IpDomain {
+			if ipd.Mtu == nil {
+				continue
+			}
+			minMTU := uint16(MinMTU)
+			if ipd.Subnet != nil {
+				if ip, _, err := net.ParseCIDR(*ipd.Subnet); (err == nil) && (ip.To4() == nil) {
+					minMTU = MinMTUv6
+				}
+			}
+			if (*ipd.Mtu < minMTU) || (*ipd.Mtu > MaxMTU) {
+				r.Errorf(ipDomainPath(entID, siteID, ipdID)+"/mtu", "MTU %d must be %d to %d", *ipd.Mtu, minMTU, MaxMTU)
+			}
+		}
+	})
+}
+
+func checkDnn(device *models.Device, r *Reporter) {
+	forEachSite(device, func(entID string, siteID string, site *models.OnfEnterprise_Enterprises_Enterprise_Site) {
+		for ipdID, ipd := range site.IpDomain {
+			if ipd.Dnn == nil {
+				continue
+			}
+			dnn := *ipd.Dnn
+			path := ipDomainPath(entID, siteID, ipdID) + "/dnn"
+			if len(dnn) > MaxDnnLength {
+				r.Errorf(path, "DNN %s is longer than %d characters", dnn, MaxDnnLength)
+			}
+			if !dnnRegexp.MatchString(dnn) {
+				r.Errorf(path, "DNN %s must be labels of letters, digits and hyphens, separated by dots", dnn)
+			}
+			lower := strings.ToLower(dnn)
+			if strings.HasSuffix(lower, ".gprs") {
+				r.Errorf(path, "DNN %s may not end in .gprs", dnn)
+			}
+			for _, prefix := range dnnBadPrefixes {
+				if strings.HasPrefix(lower, prefix) {
+					r.Errorf(path, "DNN %s may not start with %s", dnn, prefix)
+				}
+			}
+		}
+	})
+}
+
+// addressProblem returns why s is not a valid IPv4 or IPv6 address, or prefix if
+// needPrefix is set, or "" if it is valid. An address with no prefix length is allowed
+// where needPrefix is not set. IPv4-mapped IPv6 addresses mix the two families, and are
+// not allowed.
+func addressProblem(s string, needPrefix bool) string {
+	addr := s
+	if slash := strings.Index(s, "/"); slash >= 0 {
+		if _, _, err := net.ParseCIDR(s); err != nil {
+			return "is not a valid prefix"
+		}
+		addr = s[:slash]
+	} else if needPrefix {
+		return "is not a prefix"
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return "is not an IP address"
+	}
+	if (ip.To4() != nil) && strings.Contains(addr, ":") {
+		return "mixes IPv4 and IPv6"
+	}
+	return ""
+}
+
+func checkAddresses(device *models.Device, r *Reporter) {
+	forEachSite(device, func(entID string, siteID string, site *models.OnfEnterprise_Enterprises_Enterprise_Site) {
+		for ipdID, ipd := range site.IpDomain {
+			if ipd.Subnet == nil {
+				continue
+			}
+			if problem := addressProblem(*ipd.Subnet, true); problem != "" {
+				r.Errorf(ipDomainPath(entID, siteID, ipdID)+"/subnet", "subnet %s %s", *ipd.Subnet, problem)
+			}
+		}
+	})
+
+	if device.Enterprises == nil {
+		return
+	}
+	for entID, enterprise := range device.Enterprises.Enterprise {
+		for appID, app := range enterprise.Application {
+			if app.Address == nil {
+				continue
+			}
+			// An application address is a list of addresses, prefixes and host names
+			path := fmt.Sprintf("%s/application[application-id=%s]/address", enterprisePath(entID), appID)
+			for _, address := range strings.FieldsFunc(*app.Address, func(c rune) bool { return (c == ',') || unicode.IsSpace(c) }) {
+				if hostRegexp.MatchString(address) {
+					continue
+				}
+				if problem := addressProblem(address, false); problem != "" {
+					r.Errorf(path, "address %s %s", address, problem)
+				}
+			}
+		}
+	}
+}
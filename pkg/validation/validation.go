@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package validation checks an Aether 2.0 config against a set of semantic rules, such as
+// ranges, formats, and consistency between related fields, that the YANG schema does not
+// express. Every rule is run, and every problem found is reported.
+package validation
+
+import (
+	"fmt"
+	"sort"
+
+	models "github.com/onosproject/aether-models/models/aether-2.0.x/api"
+)
+
+// Severity is how serious a finding is
+type Severity string
+
+const (
+	// SeverityError is a finding that will cause synchronization to fail, or the core to
+	// reject or misbehave
+	SeverityError Severity = "error"
+
+	// SeverityWarning is a finding that is allowed, but is probably a mistake
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is one problem found by a rule
+type Finding struct {
+	Severity Severity `json:"severity"`
+	Rule     string   `json:"rule"`
+	Path     string   `json:"path"`
+	Message  string   `json:"message"`
+}
+
+// Rule is a named check over a whole device
+type Rule struct {
+	Name  string
+	Check func(device *models.Device, r *Reporter)
+}
+
+// Reporter collects the findings of a rule
+type Reporter struct {
+	rule     string
+	findings []Finding
+}
+
+// Errorf reports an error at path
+func (r *Reporter) Errorf(path string, format string, args ...interface{}) {
+	r.report(SeverityError, path, format, args...)
+}
+
+// Warnf reports a warning at path
+func (r *Reporter) Warnf(path string, format string, args ...interface{}) {
+	r.report(SeverityWarning, path, format, args...)
+}
+
+func (r *Reporter) report(severity Severity, path string, format string, args ...interface{}) {
+	r.findings = append(r.findings, Finding{
+		Severity: severity,
+		Rule:     r.rule,
+		Path:     path,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Validate runs rules against device, and returns their findings sorted by path. If no
+// rules are given, DefaultRules are run.
+func Validate(device *models.Device, rules ...Rule) []Finding {
+	if len(rules) == 0 {
+		rules = DefaultRules
+	}
+
+	r := &Reporter{findings: []Finding{}}
+	for _, rule := range rules {
+		r.rule = rule.Name
+		rule.Check(device, r)
+	}
+
+	sort.SliceStable(r.findings, func(i, j int) bool {
+		if r.findings[i].Path != r.findings[j].Path {
+			return r.findings[i].Path < r.findings[j].Path
+		}
+		return r.findings[i].Rule < r.findings[j].Rule
+	})
+
+	return r.findings
+}
+
+// ValidateJSON unmarshals an RFC7951 JSON config and validates it
+func ValidateJSON(data []byte, rules ...Rule) ([]Finding, error) {
+	device := &models.Device{}
+	if err := models.Unmarshal(data, device); err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal config: %v", err)
+	}
+	return Validate(device, rules...), nil
+}
+
+// HasErrors returns true if any of findings is an error
+func HasErrors(findings []Finding) bool {
+	for _, finding := range findings {
+		if finding.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
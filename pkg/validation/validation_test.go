@@ -0,0 +1,210 @@
+// SPDX-FileCopyrightText: 2022-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package validation
+
+import (
+	models "github.com/onosproject/aether-models/models/aether-2.0.x/api"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const (
+	testSite  = "enterprises/enterprise[enterprise-id=ent1]/site[site-id=site1]"
+	testIpd   = testSite + "/ip-domain[ip-domain-id=ipd1]"
+	testSlice = testSite + "/slice[slice-id=slice1]"
+	testEp    = "enterprises/enterprise[enterprise-id=ent1]/application[application-id=app1]/endpoint[endpoint-id=ep1]"
+)
+
+// buildDevice builds an enterprise with one site that passes every default rule
+func buildDevice() *models.Device {
+	site := &models.OnfEnterprise_Enterprises_Enterprise_Site{
+		SiteId: ygot.String("site1"),
+		ImsiDefinition: &models.OnfEnterprise_Enterprises_Enterprise_Site_ImsiDefinition{
+			Mcc:        ygot.String("123"),
+			Mnc:        ygot.String("456"),
+			Enterprise: ygot.Uint32(789),
+			Format:     ygot.String(DefaultImsiFormat),
+		},
+		SimCard: map[string]*models.OnfEnterprise_Enterprises_Enterprise_Site_SimCard{
+			"sim1": {SimId: ygot.String("sim1"), Imsi: ygot.Uint64(1)},
+		},
+		SmallCell: map[string]*models.OnfEnterprise_Enterprises_Enterprise_Site_SmallCell{
+			"sc1": {SmallCellId: ygot.String("sc1"), Tac: ygot.String("77AB")},
+		},
+		IpDomain: map[string]*models.OnfEnterprise_Enterprises_Enterprise_Site_IpDomain{
+			"ipd1": {
+				IpDomainId: ygot.String("ipd1"),
+				Dnn:        ygot.String("5ginternet"),
+				Subnet:     ygot.String("10.250.0.0/16"),
+				DnsPrimary: ygot.String("8.8.8.8"),
+				Mtu:        ygot.Uint16(1492),
+			},
+		},
+		Slice: map[string]*models.OnfEnterprise_Enterprises_Enterprise_Site_Slice{
+			"slice1": {
+				SliceId: ygot.String("slice1"),
+				Sst:     ygot.Uint8(1),
+				Sd:      ygot.Uint32(0x111),
+				Mbr: &models.OnfEnterprise_Enterprises_Enterprise_Site_Slice_Mbr{
+					Uplink:            ygot.Uint64(10000000),
+					UplinkBurstSize:   ygot.Uint32(625000),
+					Downlink:          ygot.Uint64(20000000),
+					DownlinkBurstSize: ygot.Uint32(625000),
+				},
+			},
+		},
+	}
+
+	enterprise := &models.OnfEnterprise_Enterprises_Enterprise{
+		EnterpriseId: ygot.String("ent1"),
+		Application: map[string]*models.OnfEnterprise_Enterprises_Enterprise_Application{
+			"app1": {
+				ApplicationId: ygot.String("app1"),
+				Address:       ygot.String("2001:db8::10, 10.1.0.0/16 saas.example.com"),
+				Endpoint: map[string]*models.OnfEnterprise_Enterprises_Enterprise_Application_Endpoint{
+					"ep1": {EndpointId: ygot.String("ep1"), PortStart: ygot.Uint16(80), PortEnd: ygot.Uint16(88), Protocol: ygot.String("sctp")},
+				},
+			},
+		},
+		Template: map[string]*models.OnfEnterprise_Enterprises_Enterprise_Template{
+			"tp1": {TemplateId: ygot.String("tp1"), Sst: ygot.Uint8(1)},
+		},
+		Site: map[string]*models.OnfEnterprise_Enterprises_Enterprise_Site{"site1": site},
+	}
+
+	return &models.Device{
+		Enterprises: &models.OnfEnterprise_Enterprises{
+			Enterprise: map[string]*models.OnfEnterprise_Enterprises_Enterprise{"ent1": enterprise},
+		},
+	}
+}
+
+func TestValidateClean(t *testing.T) {
+	findings := Validate(buildDevice())
+	assert.Empty(t, findings)
+	assert.False(t, HasErrors(findings))
+
+	// A device with nothing in it has nothing to report
+	assert.Empty(t, Validate(&models.Device{}))
+
+	// A sim-card IMSI long enough to be a full IMSI is not subscriber digits
+	device := buildDevice()
+	device.Enterprises.Enterprise["ent1"].Site["site1"].SimCard["sim1"].Imsi = ygot.Uint64(1234567890123)
+	assert.Empty(t, Validate(device))
+}
+
+func TestValidateRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(site *models.OnfEnterprise_Enterprises_Enterprise_Site, ent *models.OnfEnterprise_Enterprises_Enterprise)
+		finding Finding
+	}{
+		{"sst", func(site *models.OnfEnterprise_Enterprises_Enterprise_Site, ent *models.OnfEnterprise_Enterprises_Enterprise) {
+			site.Slice["slice1"].Sst = ygot.Uint8(0)
+		}, Finding{SeverityError, "sst-sd", testSlice + "/sst", "SST 0 is reserved; it must be 1 to 255"}},
+		{"sd", func(site *models.OnfEnterprise_Enterprises_Enterprise_Site, ent *models.OnfEnterprise_Enterprises_Enterprise) {
+			ent.Template["tp1"].Sd = ygot.Uint32(0x1000000)
+		}, Finding{SeverityError, "sst-sd", "enterprises/enterprise[enterprise-id=ent1]/template[template-id=tp1]/sd", "SD 16777216 does not fit in 24 bits"}},
+		{"tac", func(site *models.OnfEnterprise_Enterprises_Enterprise_Site, ent *models.OnfEnterprise_Enterprises_Enterprise) {
+			site.SmallCell["sc1"].Tac = ygot.String("77XY")
+		}, Finding{SeverityError, "tac", testSite + "/small-cell[small-cell-id=sc1]/tac", "TAC 77XY must be 4 to 6 hex digits"}},
+		{"mcc", func(site *models.OnfEnterprise_Enterprises_Enterprise_Site, ent *models.OnfEnterprise_Enterprises_Enterprise) {
+			site.ImsiDefinition.Mcc = ygot.String("12a")
+		}, Finding{SeverityError, "imsi", testSite + "/imsi-definition/mcc", "MCC 12a must be 3 digits"}},
+		{"mnc digits", func(site *models.OnfEnterprise_Enterprises_Enterprise_Site, ent *models.OnfEnterprise_Enterprises_Enterprise) {
+			site.ImsiDefinition.Format = ygot.String("CCCNNEEEESSSSSS")
+		}, Finding{SeverityError, "imsi", testSite + "/imsi-definition/format", "format CCCNNEEEESSSSSS has 2 MNC digits, but MNC 456 has 3"}},
+		{"mnc padded", func(site *models.OnfEnterprise_Enterprises_Enterprise_Site, ent *models.OnfEnterprise_Enterprises_Enterprise) {
+			site.ImsiDefinition.Mnc = ygot.String("45")
+		}, Finding{SeverityWarning, "imsi", testSite + "/imsi-definition/format", "format CCCNNNEEESSSSSS has 3 MNC digits, so MNC 45 is padded with leading zeros"}},
+		{"enterprise", func(site *models.OnfEnterprise_Enterprises_Enterprise_Site, ent *models.OnfEnterprise_Enterprises_Enterprise) {
+			site.ImsiDefinition.Enterprise = ygot.Uint32(7890)
+		}, Finding{SeverityError, "imsi", testSite + "/imsi-definition/enterprise", "enterprise 7890 has 4 digits, but format CCCNNNEEESSSSSS has room for 3"}},
+		{"sim imsi", func(site *models.OnfEnterprise_Enterprises_Enterprise_Site, ent *models.OnfEnterprise_Enterprises_Enterprise) {
+			site.SimCard["sim1"].Imsi = ygot.Uint64(1234567)
+		}, Finding{SeverityError, "imsi", testSite + "/sim-card[sim-id=sim1]/imsi", "IMSI 1234567 has 7 digits, but the site's format has room for 6 subscriber digits"}},
+		{"sim imsi length", func(site *models.OnfEnterprise_Enterprises_Enterprise_Site, ent *models.OnfEnterprise_Enterprises_Enterprise) {
+			site.SimCard["sim1"].Imsi = ygot.Uint64(1234567890123456)
+		}, Finding{SeverityError, "imsi", testSite + "/sim-card[sim-id=sim1]/imsi", "IMSI 1234567890123456 has 16 digits; an IMSI has 15"}},
+		{"port range", func(site *models.OnfEnterprise_Enterprises_Enterprise_Site, ent *models.OnfEnterprise_Enterprises_Enterprise) {
+			ent.Application["app1"].Endpoint["ep1"].PortEnd = ygot.Uint16(79)
+		}, Finding{SeverityError, "port-range", testEp + "/port-end", "port-end 79 is before port-start 80"}},
+		{"port zero", func(site *models.OnfEnterprise_Enterprises_Enterprise_Site, ent *models.OnfEnterprise_Enterprises_Enterprise) {
+			ent.Application["app1"].Endpoint["ep1"].PortStart = ygot.Uint16(0)
+		}, Finding{SeverityWarning, "port-range", testEp + "/port-start", "port 0 is reserved"}},
+		{"protocol", func(site *models.OnfEnterprise_Enterprises_Enterprise_Site, ent *models.OnfEnterprise_Enterprises_Enterprise) {
+			ent.Application["app1"].Endpoint["ep1"].Protocol = ygot.String("MQTT")
+		}, Finding{SeverityError, "port-range", testEp + "/protocol", "protocol MQTT is not an IANA protocol name or number"}},
+		{"protocol without ports", func(site *models.OnfEnterprise_Enterprises_Enterprise_Site, ent *models.OnfEnterprise_Enterprises_Enterprise) {
+			ent.Application["app1"].Endpoint["ep1"].Protocol = ygot.String("ICMP")
+		}, Finding{SeverityError, "port-range", testEp + "/protocol", "protocol ICMP has no ports, but port-start is set"}},
+		{"burst zero", func(site *models.OnfEnterprise_Enterprises_Enterprise_Site, ent *models.OnfEnterprise_Enterprises_Enterprise) {
+			site.Slice["slice1"].Mbr.UplinkBurstSize = ygot.Uint32(0)
+		}, Finding{SeverityError, "mbr", testSlice + "/mbr/uplink-burst-size", "uplink burst size of 0 would drop every packet"}},
+		{"burst over rate", func(site *models.OnfEnterprise_Enterprises_Enterprise_Site, ent *models.OnfEnterprise_Enterprises_Enterprise) {
+			site.Slice["slice1"].Mbr.Downlink = ygot.Uint64(1000000)
+		}, Finding{SeverityWarning, "mbr", testSlice + "/mbr/downlink-burst-size", "downlink burst size of 625000 bytes is more than one second of traffic at 1000000 bps"}},
+		{"dns", func(site *models.OnfEnterprise_Enterprises_Enterprise_Site, ent *models.OnfEnterprise_Enterprises_Enterprise) {
+			site.IpDomain["ipd1"].DnsPrimary = ygot.String("dns.example.com")
+		}, Finding{SeverityError, "dns", testIpd + "/dns-primary", "DNS server dns.example.com is not an IP address"}},
+		{"mtu", func(site *models.OnfEnterprise_Enterprises_Enterprise_Site, ent *models.OnfEnterprise_Enterprises_Enterprise) {
+			site.IpDomain["ipd1"].Mtu = ygot.Uint16(500)
+		}, Finding{SeverityError, "mtu", testIpd + "/mtu", "MTU 500 must be 576 to 9000"}},
+		{"mtu ipv6", func(site *models.OnfEnterprise_Enterprises_Enterprise_Site, ent *models.OnfEnterprise_Enterprises_Enterprise) {
+			site.IpDomain["ipd1"].Subnet = ygot.String("2001:db8:1::/64")
+			site.IpDomain["ipd1"].Mtu = ygot.Uint16(1000)
+		}, Finding{SeverityError, "mtu", testIpd + "/mtu", "MTU 1000 must be 1280 to 9000"}},
+		{"dnn", func(site *models.OnfEnterprise_Enterprises_Enterprise_Site, ent *models.OnfEnterprise_Enterprises_Enterprise) {
+			site.IpDomain["ipd1"].Dnn = ygot.String("internet.gprs")
+		}, Finding{SeverityError, "dnn", testIpd + "/dnn", "DNN internet.gprs may not end in .gprs"}},
+		{"subnet", func(site *models.OnfEnterprise_Enterprises_Enterprise_Site, ent *models.OnfEnterprise_Enterprises_Enterprise) {
+			site.IpDomain["ipd1"].Subnet = ygot.String("10.250.0.1")
+		}, Finding{SeverityError, "address", testIpd + "/subnet", "subnet 10.250.0.1 is not a prefix"}},
+		{"subnet length", func(site *models.OnfEnterprise_Enterprises_Enterprise_Site, ent *models.OnfEnterprise_Enterprises_Enterprise) {
+			site.IpDomain["ipd1"].Subnet = ygot.String("2001:db8::/129")
+		}, Finding{SeverityError, "address", testIpd + "/subnet", "subnet 2001:db8::/129 is not a valid prefix"}},
+		{"application address", func(site *models.OnfEnterprise_Enterprises_Enterprise_Site, ent *models.OnfEnterprise_Enterprises_Enterprise) {
+			ent.Application["app1"].Address = ygot.String("10.0.0.1,::ffff:10.0.0.1")
+		}, Finding{SeverityError, "address", "enterprises/enterprise[enterprise-id=ent1]/application[application-id=app1]/address",
+			"address ::ffff:10.0.0.1 mixes IPv4 and IPv6"}},
+	}
+
+	for _, test := range tests {
+		device := buildDevice()
+		ent := device.Enterprises.Enterprise["ent1"]
+		test.modify(ent.Site["site1"], ent)
+		assert.Equal(t, []Finding{test.finding}, Validate(device), test.name)
+	}
+}
+
+func TestValidateAllFindings(t *testing.T) {
+	device := buildDevice()
+	site := device.Enterprises.Enterprise["ent1"].Site["site1"]
+	site.IpDomain["ipd1"].Mtu = ygot.Uint16(100)
+	site.IpDomain["ipd1"].Dnn = ygot.String("-bad")
+	site.Slice["slice1"].Sst = ygot.Uint8(0)
+
+	// Every problem is reported, sorted by path, rather than just the first
+	findings := Validate(device)
+	assert.True(t, HasErrors(findings))
+	assert.Equal(t, []string{testIpd + "/dnn", testIpd + "/mtu", testSlice + "/sst"},
+		[]string{findings[0].Path, findings[1].Path, findings[2].Path})
+	assert.Len(t, findings, 3)
+
+	// Only the rules given are run
+	assert.Equal(t, []Finding{{SeverityError, "mtu", testIpd + "/mtu", "MTU 100 must be 576 to 9000"}},
+		Validate(device, Rule{Name: "mtu", Check: checkMtu}))
+}
+
+func TestValidateJSON(t *testing.T) {
+	findings, err := ValidateJSON([]byte(`{"enterprises": {"enterprise": [{"enterprise-id": "ent1",
+		"site": [{"site-id": "site1", "small-cell": [{"small-cell-id": "sc1", "tac": "1"}]}]}]}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []Finding{{SeverityError, "tac", testSite + "/small-cell[small-cell-id=sc1]/tac", "TAC 1 must be 4 to 6 hex digits"}}, findings)
+
+	_, err = ValidateJSON([]byte(`not json`))
+	assert.NotNil(t, err)
+}